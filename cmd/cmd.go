@@ -1,16 +1,23 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/cicontext"
 	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/domain"
 	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/flags"
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/loader"
 	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/mail"
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/provider"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
 )
 
 const (
@@ -22,16 +29,25 @@ const (
 	// The environment variable prefix of all environment variables bound to our command line flags.
 	// For example, --number is bound to STING_NUMBER.
 	envPrefix = ""
+
+	// The environment variable prefix of the plugin settings of drone. For
+	// example, the setting smtp_host is passed as PLUGIN_SMTP_HOST.
+	pluginEnvPrefix = "PLUGIN"
 )
 
 func Execute(version string) error {
 	rootCmd := &cobra.Command{
 		Use: "drone-email",
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			return initializeConfig(cmd)
+			err := initializeConfig(cmd)
+			if err != nil {
+				return err
+			}
+
+			return validateFailurePolicy(cmd)
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			vars, err := newHTMLTemplateVarsByCommand(cmd)
+			vars, err := newCIVarsByCommand(cmd)
 			if err != nil {
 				return fmt.Errorf("failed to initialize new html template vars: %w", err)
 			}
@@ -41,17 +57,93 @@ func Execute(version string) error {
 				return fmt.Errorf("failed to initialize new config vars: %w", err)
 			}
 
+			recipientSettings, err := newRecipientSettingsByCommand(cmd)
+			if err != nil {
+				return fmt.Errorf("failed to initialize new recipient settings: %w", err)
+			}
+
+			filterSettings, err := newFilterSettingsByCommand(cmd)
+			if err != nil {
+				return fmt.Errorf("failed to initialize new filter settings: %w", err)
+			}
+
 			recipients, err := cmd.Flags().GetStringArray(flags.SMTP_TO_ADDRESSES)
 			if err != nil {
 				return fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_TO_ADDRESSES, err)
 			}
 
-			err = mail.NewPlugin(smtpSettings).Exec(cmd.Context(), recipients, vars)
+			scheduleSettings, err := newScheduleSettingsByCommand(cmd)
 			if err != nil {
-				return fmt.Errorf("failed to execute mail plugin: %w", err)
+				return fmt.Errorf("failed to initialize new schedule settings: %w", err)
 			}
 
-			_, err = fmt.Fprint(os.Stdout, "E-Mails successfully sent")
+			stateSettings, err := newStateSettingsByCommand(cmd)
+			if err != nil {
+				return fmt.Errorf("failed to initialize new state settings: %w", err)
+			}
+
+			escalationSettings, err := newEscalationSettingsByCommand(cmd)
+			if err != nil {
+				return fmt.Errorf("failed to initialize new escalation settings: %w", err)
+			}
+
+			digestSettings, err := newDigestSettingsByCommand(cmd)
+			if err != nil {
+				return fmt.Errorf("failed to initialize new digest settings: %w", err)
+			}
+
+			droneAPISettings, err := newDroneAPISettingsByCommand(cmd)
+			if err != nil {
+				return fmt.Errorf("failed to initialize new drone API settings: %w", err)
+			}
+
+			dryRunSettings, err := newDryRunSettingsByCommand(cmd)
+			if err != nil {
+				return fmt.Errorf("failed to initialize new dry run settings: %w", err)
+			}
+
+			deploymentSettings, err := newDeploymentSettingsByCommand(cmd)
+			if err != nil {
+				return fmt.Errorf("failed to initialize new deployment settings: %w", err)
+			}
+
+			templateSettings, err := newTemplateSettingsByCommand(cmd)
+			if err != nil {
+				return fmt.Errorf("failed to initialize new template settings: %w", err)
+			}
+
+			plugin := mail.NewPlugin(&mail.Settings{
+				Deployment: deploymentSettings,
+				Digest:     digestSettings,
+				DroneAPI:   droneAPISettings,
+				DryRun:     dryRunSettings,
+				Escalation: escalationSettings,
+				Filter:     filterSettings,
+				Recipient:  recipientSettings,
+				Schedule:   scheduleSettings,
+				SMTP:       smtpSettings,
+				State:      stateSettings,
+				Template:   templateSettings,
+			})
+
+			err = plugin.Exec(cmd.Context(), recipients, vars)
+			skipErr := new(mail.SkipError)
+			switch {
+			case errors.As(err, &skipErr):
+				_, err = fmt.Fprintf(os.Stdout, "E-Mails skipped: %s", skipErr.Reason)
+				if err != nil {
+					return fmt.Errorf("failed to write message on stdout: %w", err)
+				}
+				return nil
+			case err != nil:
+				return applyFailurePolicy(cmd, fmt.Errorf("failed to execute mail plugin: %w", err))
+			}
+
+			if dryRunSettings.Enabled {
+				_, err = fmt.Fprint(os.Stdout, "Dry run finished, no E-Mails sent")
+			} else {
+				_, err = fmt.Fprint(os.Stdout, "E-Mails successfully sent")
+			}
 			if err != nil {
 				return fmt.Errorf("failed to write message on stdout: %w", err)
 			}
@@ -69,71 +161,109 @@ func Execute(version string) error {
 
 	// Drone environment variables/flags
 	// Flags which receive their values from environment variables of the drone
-	// CI/CD.
-	//
-	// The names of the FLags must match the environment variables, otherwise the
-	// environment variables will not be bound correctly to the flags.
-	rootCmd.Flags().Int64(flags.DRONE_BUILD_CREATED, 0, "Build created")
-	rootCmd.Flags().Int64(flags.DRONE_BUILD_FINISHED, 0, "Build finished")
-	rootCmd.Flags().Int64(flags.DRONE_BUILD_STARTED, 0, "Build stared")
-	rootCmd.Flags().String(flags.DRONE_BUILD_EVENT, "push", "Build event")
-	rootCmd.Flags().String(flags.DRONE_BUILD_LINK, "", "Build link")
-	rootCmd.Flags().Int(flags.DRONE_BUILD_NUMBER, 0, "Build number")
-	rootCmd.Flags().String(flags.DRONE_BUILD_STATUS, "success", "Build status")
-
-	rootCmd.Flags().String(flags.DRONE_COMMIT_SHA, "", "SHA sum of the commit")
-	rootCmd.Flags().String(flags.DRONE_COMMIT_REF, "refs/heads/master", "Commit reference")
-	rootCmd.Flags().String(flags.DRONE_COMMIT_BRANCH, "master", "Commit branch")
-	rootCmd.Flags().String(flags.DRONE_COMMIT_LINK, "", "Link to the commit")
-	rootCmd.Flags().String(flags.DRONE_COMMIT_MESSAGE, "", "Commit message")
-	rootCmd.Flags().String(flags.DRONE_COMMIT_AUTHOR_NAME, "", "Name of the commit author")
-	rootCmd.Flags().String(flags.DRONE_COMMIT_AUTHOR_EMAIL, "", "E-Mail of the commit author")
-	rootCmd.Flags().String(flags.DRONE_COMMIT_AUTHOR_AVATAR, "", "Avatar of the commit author")
-
-	rootCmd.Flags().String(flags.DRONE_DEPLOY_TO, "", "Deploy target")
-
-	rootCmd.Flags().String(flags.DRONE_JOB_NUMBER, "", "Job number")
-	rootCmd.Flags().String(flags.DRONE_JOB_STATUS, "", "Job status")
-	rootCmd.Flags().Int(flags.DRONE_JOB_EXIT_CODE, 0, "Job exit code")
-	rootCmd.Flags().Int(flags.DRONE_JOB_STARTED, 0, "Job started")
-	rootCmd.Flags().Int(flags.DRONE_JOB_FINISHED, 0, "Job finished")
-
-	rootCmd.Flags().String(flags.DRONE_PREV_BUILD_STATUS, "", "Previous build status")
-	rootCmd.Flags().Int(flags.DRONE_PREV_BUILD_NUMBER, 0, "Previous build number")
-	rootCmd.Flags().String(flags.DRONE_PREV_COMMIT_SHA, "", "Previous commit sha sum")
-
-	rootCmd.Flags().Int(flags.DRONE_PULL_REQUEST, 0, "Number of pull-request")
-
-	rootCmd.Flags().String(flags.DRONE_REMOTE_URL, "", "Clone URL of the repository")
-
-	rootCmd.Flags().Bool(flags.DRONE_REPO_PRIVATE, true, "Repository is private")
-	rootCmd.Flags().Bool(flags.DRONE_REPO_TRUSTED, false, "Repository is trusted")
-	rootCmd.Flags().String(flags.DRONE_REPO_AVATAR, "", "Avatar URL of the repository")
-	rootCmd.Flags().String(flags.DRONE_REPO_BRANCH, "master", "Branch of the repository")
-	rootCmd.Flags().String(flags.DRONE_REPO_LINK, "", "URL to the repository")
-	rootCmd.Flags().String(flags.DRONE_REPO_NAME, "", "Name of the repository")
-	rootCmd.Flags().String(flags.DRONE_REPO_OWNER, "", "Name of the repository owner")
-	rootCmd.Flags().String(flags.DRONE_REPO_SCM, "git", "Source code management provider")
-	rootCmd.Flags().String(flags.DRONE_REPO, "", "Full name of the repository")
-
-	rootCmd.Flags().String(flags.DRONE_TAG, "", "Tag")
-
-	rootCmd.Flags().Bool(flags.DRONE_YAML_SIGNED, false, "YAML is signed")
-	rootCmd.Flags().Bool(flags.DRONE_YAML_VERIFIED, false, "YAML is verified")
+	// CI/CD. The flags are generated from the env tags of the fields of
+	// mail.CIVars.
+	err = loader.AddFlags(rootCmd.Flags(), &mail.CIVars{})
+	if err != nil {
+		return fmt.Errorf("failed to add flags of CI vars: %w", err)
+	}
+
+	// CONTEXT FILE
+	rootCmd.Flags().String(flags.CONTEXT_FILE, "", "Path to a JSON or YAML file of the CI vars, e.g. build and commit, or - for stdin. Flags and environment variables override individual values")
+
+	// CI PROVIDER
+	rootCmd.Flags().String(flags.CI_PROVIDER, provider.NameAuto, "CI system, whose environment variables are read: auto, actions, drone, gitlab or woodpecker")
+
+	// DEPLOYMENT SETTINGS
+	rootCmd.Flags().String(flags.DEPLOY_HISTORY_FILE, "", "Path to a JSON file, which records the built commits and the last deployment to each environment")
+	rootCmd.Flags().StringArray(flags.DEPLOY_RECIPIENTS, []string{}, "List of JSON objects of recipients of deployments, e.g. {\"environment\": \"production\", \"recipients\": [\"@stakeholders\"]}")
+
+	// DIGEST SETTINGS
+	rootCmd.PersistentFlags().String(flags.DIGEST_SPOOL, "", "Path to a file or directory builds are queued to, when the notify mode is digest")
+
+	// DRONE API SETTINGS
+	rootCmd.Flags().Int(flags.DRONE_LOG_LINES, 20, "Number of the last log lines of each failed step")
+	rootCmd.Flags().String(flags.DRONE_SERVER, "", "URL of the drone server, whose API is requested. Defaults to the server of the build")
+	rootCmd.Flags().String(flags.DRONE_TOKEN, "", "Token of a drone user to fetch the stages, steps and logs of the build from the drone API")
+
+	// DRY RUN SETTINGS
+	rootCmd.PersistentFlags().Bool(flags.DRY_RUN, false, "Print the mails instead of sending them")
+	rootCmd.PersistentFlags().Bool(flags.DRY_RUN_SMTP, false, "Verify the SMTP session up to the RCPT command in a dry run")
+
+	// FAILURE SETTINGS
+	rootCmd.PersistentFlags().String(flags.FAILURE_POLICY, domain.FailurePolicyFail, "Handling of errors delivering mails: fail, warn or ignore")
+
+	// ESCALATION SETTINGS
+	rootCmd.Flags().String(flags.ESCALATION_HISTORY_FILE, "", "Path to a JSON file, which counts the consecutive failed builds of each branch")
+	rootCmd.Flags().StringArray(flags.ESCALATION_LEVELS, []string{}, "List of JSON objects of escalation levels, e.g. {\"threshold\": 3, \"recipients\": [\"lead@example.com\"]}")
+
+	// NOTIFY SETTINGS
+	rootCmd.Flags().String(flags.NOTIFY_CONDITION, domain.ConditionAlways, "Condition based on the build status transition to send mails: always, change, failure, fixed or failure-and-fixed")
+	rootCmd.Flags().StringArray(flags.NOTIFY_EXCLUDE_BRANCHES, []string{}, "Glob patterns of commit branches to send no mails for")
+	rootCmd.Flags().StringArray(flags.NOTIFY_EXCLUDE_DEPLOY_TO, []string{}, "Deploy targets to send no mails for")
+	rootCmd.Flags().StringArray(flags.NOTIFY_EXCLUDE_EVENTS, []string{}, "Build events to send no mails for")
+	rootCmd.Flags().StringArray(flags.NOTIFY_EXCLUDE_REPOS, []string{}, "Glob patterns of full repository names to send no mails for")
+	rootCmd.Flags().StringArray(flags.NOTIFY_EXCLUDE_STATUSES, []string{}, "Build statuses to send no mails for")
+	rootCmd.Flags().StringArray(flags.NOTIFY_EXCLUDE_TAGS, []string{}, "Regular expressions of tags to send no mails for")
+	rootCmd.Flags().StringArray(flags.NOTIFY_INCLUDE_BRANCHES, []string{}, "Glob patterns of commit branches to send mails for")
+	rootCmd.Flags().StringArray(flags.NOTIFY_INCLUDE_DEPLOY_TO, []string{}, "Deploy targets to send mails for")
+	rootCmd.Flags().StringArray(flags.NOTIFY_INCLUDE_EVENTS, []string{}, "Build events to send mails for")
+	rootCmd.Flags().StringArray(flags.NOTIFY_INCLUDE_REPOS, []string{}, "Glob patterns of full repository names to send mails for")
+	rootCmd.Flags().StringArray(flags.NOTIFY_INCLUDE_STATUSES, []string{}, "Build statuses to send mails for")
+	rootCmd.Flags().StringArray(flags.NOTIFY_INCLUDE_TAGS, []string{}, "Regular expressions of tags to send mails for")
+	rootCmd.Flags().String(flags.NOTIFY_MODE, domain.NotifyModeInstant, "Send mails instantly or queue the builds for a digest: instant or digest")
+	rootCmd.Flags().String(flags.NOTIFY_WHEN, "", "Expression which must evaluate to true to send mails, e.g. build.status != \"success\"")
+
+	// SCHEDULE SETTINGS
+	rootCmd.Flags().String(flags.SCHEDULE_ACTION, domain.ScheduleActionSkip, "Action for mails of builds finished outside of the schedule: skip, on-call or spool")
+	rootCmd.Flags().String(flags.SCHEDULE_HOLIDAY_FILE, "", "Path to a file of holidays in the format 2006-01-02, one per line")
+	rootCmd.Flags().String(flags.SCHEDULE_ON_CALL_ADDRESS, "", "Address which receives the mails outside of the schedule, when the action is on-call")
+	rootCmd.PersistentFlags().String(flags.SCHEDULE_SPOOL_DIR, "", "Directory mails are spooled to outside of the schedule, when the action is spool")
+	rootCmd.Flags().String(flags.SCHEDULE_TIMEZONE, "Local", "Timezone of the schedule, e.g. Europe/Berlin")
+	rootCmd.Flags().StringArray(flags.SCHEDULE_WEEKDAYS, []string{}, "Weekdays on which mails are sent, e.g. mon or fri")
+	rootCmd.Flags().StringArray(flags.SCHEDULE_WINDOWS, []string{}, "Daily time windows in which mails are sent, e.g. 08:00-18:00")
+
+	// STATE SETTINGS
+	rootCmd.PersistentFlags().String(flags.STATE_FILE, "", "Path to a JSON file of sent notifications to suppress duplicates, e.g. on a cache volume")
+	rootCmd.PersistentFlags().Duration(flags.STATE_WINDOW, time.Hour, "Duration in which an equivalent notification is not sent again")
+
+	// TEMPLATE SETTINGS
+	rootCmd.PersistentFlags().StringArray(flags.DATA_FILE, []string{}, "Paths to JSON or YAML files, whose documents are available in the templates as .Data")
+	rootCmd.PersistentFlags().StringArray(flags.VAR, []string{}, "Template variable as key=value, available in the templates as .Vars, e.g. image.tag=1.2.3")
+	rootCmd.PersistentFlags().String(flags.VARS, "", "JSON or YAML object of template variables, available in the templates as .Vars")
 
 	// MAIL SETTINGS
-	rootCmd.Flags().Bool(flags.SMTP_START_TLS, mail.DefaultSMTPStartTLS, "Use StartTLS instead of SSL")
-	rootCmd.Flags().Bool(flags.SMTP_TLS_INSECURE_SKIP_VERIFY, mail.DefaultSMTPTLSInsecureSkipVerify, "Trust insecure TLS certificates")
-	rootCmd.Flags().Int(flags.SMTP_PORT, mail.DefaultSMTPPort, "SMTP-Port")
-	rootCmd.Flags().String(flags.SMTP_FROM_ADDRESS, mail.DefaultSMTPFromAddress, "SMTP-From Address")
-	rootCmd.Flags().String(flags.SMTP_FROM_NAME, mail.DefaultSMTPFromName, "SMTP-From Name")
-	rootCmd.Flags().String(flags.SMTP_HELO, hostname, "SMTP-HELO/EHLO")
-	rootCmd.Flags().String(flags.SMTP_HOST, mail.DefaultSMTPHost, "SMTP-Host")
-	rootCmd.Flags().String(flags.SMTP_PASSWORD, "", "SMTP-Password")
-	rootCmd.Flags().String(flags.SMTP_USERNAME, "", "SMTP-User")
-	rootCmd.Flags().StringArray(flags.SMTP_TO_ADDRESSES, []string{}, "List of recipients")
+	rootCmd.PersistentFlags().Bool(flags.SMTP_START_TLS, mail.DefaultSMTPStartTLS, "Use StartTLS instead of SSL")
+	rootCmd.PersistentFlags().Bool(flags.SMTP_TLS_INSECURE_SKIP_VERIFY, mail.DefaultSMTPTLSInsecureSkipVerify, "Trust insecure TLS certificates")
+	rootCmd.PersistentFlags().Int(flags.SMTP_PORT, mail.DefaultSMTPPort, "SMTP-Port")
+	rootCmd.PersistentFlags().String(flags.SMTP_FROM_ADDRESS, mail.DefaultSMTPFromAddress, "SMTP-From Address")
+	rootCmd.PersistentFlags().String(flags.SMTP_FROM_NAME, mail.DefaultSMTPFromName, "SMTP-From Name")
+	rootCmd.PersistentFlags().String(flags.SMTP_HELO, hostname, "SMTP-HELO/EHLO")
+	rootCmd.PersistentFlags().String(flags.SMTP_HOST, mail.DefaultSMTPHost, "SMTP-Host")
+	rootCmd.PersistentFlags().String(flags.SMTP_LIST_UNSUBSCRIBE, "", "Mailto or HTTPS URI of the List-Unsubscribe header, rendered as template per recipient")
+	rootCmd.PersistentFlags().String(flags.SMTP_OPT_OUT_FILE, "", "Path to a YAML or JSON file of recipients which opted out of mails")
+	rootCmd.PersistentFlags().String(flags.SMTP_PASSWORD, "", "SMTP-Password")
+	rootCmd.PersistentFlags().String(flags.SMTP_USERNAME, "", "SMTP-User")
+	rootCmd.PersistentFlags().StringArray(flags.SMTP_TO_ADDRESSES, []string{}, "List of recipients")
+	rootCmd.PersistentFlags().StringArray(flags.SMTP_ALLOW_LIST, []string{}, "List of addresses and domains, e.g. example.com or *.example.com, mails may be sent to")
+	rootCmd.PersistentFlags().Bool(flags.SMTP_ALLOW_LIST_PRIVATE_ONLY, false, "Apply the allow list only for private repositories")
+	rootCmd.PersistentFlags().StringArray(flags.SMTP_DENY_LIST, []string{}, "List of addresses and domains mails must not be sent to")
+	rootCmd.PersistentFlags().String(flags.SMTP_FALLBACK_ADDRESS, "", "Address which receives the mails of recipients dropped by the allow or deny list")
+	rootCmd.PersistentFlags().String(flags.SMTP_DIRECTORY_FILE, "", "Path to a YAML, JSON or CSV team directory which maps usernames, addresses and group aliases to recipients")
+	rootCmd.Flags().Bool(flags.SMTP_TO_CO_AUTHORS, false, "Add the Co-authored-by trailers of the commit message to the recipients")
+	rootCmd.Flags().StringArray(flags.SMTP_TO_CONDITIONAL, []string{}, "List of JSON objects of recipients with an expression, e.g. {\"address\": \"qa@example.com\", \"when\": \"deploy_to == 'staging'\"}")
+	rootCmd.Flags().Bool(flags.SMTP_TO_PULL_REQUEST_AUTHOR, false, "Send the mails of pull request builds to the author of the pull request instead of the author of the commit")
+	rootCmd.Flags().StringArray(flags.SMTP_TO_TRAILERS, []string{}, "List of additional commit message trailers, e.g. Reviewed-by or Signed-off-by, whose addresses are added to the recipients")
 
 	rootCmd.AddCommand(completionCmd)
+	rootCmd.AddCommand(contextCmd)
+	contextCmd.AddCommand(contextSchemaCmd)
+	rootCmd.AddCommand(digestCmd)
+	digestCmd.AddCommand(digestSendCmd)
+	rootCmd.AddCommand(flushCmd)
+	rootCmd.AddCommand(stateCmd)
+	stateCmd.AddCommand(stateListCmd)
+	stateCmd.AddCommand(statePruneCmd)
 
 	err = rootCmd.Execute()
 	if err != nil {
@@ -172,412 +302,736 @@ func initializeConfig(cmd *cobra.Command) error {
 	v.AutomaticEnv()
 
 	// Bind the current command's flags to viper
-	bindFlags(cmd, v)
+	err := bindFlags(cmd, v)
+	if err != nil {
+		return fmt.Errorf("failed to bind flags: %w", err)
+	}
 
 	return nil
 }
 
 // Bind each cobra flag to its associated viper configuration (config file and environment variable)
-func bindFlags(cmd *cobra.Command, v *viper.Viper) {
+func bindFlags(cmd *cobra.Command, v *viper.Viper) error {
+	var errs []error
 	cmd.Flags().VisitAll(func(f *pflag.Flag) {
 		// Environment variables can't have dashes in them, so bind them to their equivalent
 		// keys with underscores, e.g. --favorite-color to STING_FAVORITE_COLOR
-		if strings.Contains(f.Name, "-") {
-			envVarSuffix := strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
-			if len(envPrefix) <= 0 {
-				_ = v.BindEnv(f.Name, envVarSuffix)
-			} else {
-				_ = v.BindEnv(f.Name, fmt.Sprintf("%s_%s", envPrefix, envVarSuffix))
-			}
+		//
+		// Drone passes the settings of a plugin as environment variables with the
+		// prefix PLUGIN_, e.g. PLUGIN_SMTP_HOST. They are bound as well and take
+		// precedence over the environment variables without prefix, because viper
+		// uses the first defined environment variable. Flags without dashes, like
+		// --vars, are bound too, because AutomaticEnv does not know the prefix.
+		//
+		// Short aliases of the plugin settings, like PLUGIN_HOST, are bound after
+		// the full names.
+		envVarSuffix := strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+		envVars := []string{fmt.Sprintf("%s_%s", pluginEnvPrefix, envVarSuffix)}
+		for _, alias := range pluginSettingAliases[f.Name] {
+			envVars = append(envVars, fmt.Sprintf("%s_%s", pluginEnvPrefix, alias))
 		}
+		if len(envPrefix) <= 0 {
+			envVars = append(envVars, envVarSuffix)
+		} else {
+			envVars = append(envVars, fmt.Sprintf("%s_%s", envPrefix, envVarSuffix))
+		}
+		_ = v.BindEnv(append([]string{f.Name}, envVars...)...)
 
 		// Apply the viper config value to the flag when the flag is not set and viper has a value
 		if !f.Changed && v.IsSet(f.Name) {
-			val := v.Get(f.Name)
-			_ = cmd.Flags().Set(f.Name, fmt.Sprintf("%v", val))
+			err := setFlagValue(cmd.Flags(), f, v.Get(f.Name))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to set value of %s: %w", f.Name, err))
+			}
 		}
 	})
+
+	return errors.Join(errs...)
 }
 
-func newHTMLTemplateVarsByCommand(cmd *cobra.Command) (*mail.CIVars, error) {
-	build, err := newBuildByCommand(cmd)
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize new build struct: %w", err)
+// setFlagValue applies a value of viper to the flag. Flags holding a list of
+// values receive native lists of the config file as well as strings of
+// environment variables, which are parsed by splitListValue.
+func setFlagValue(flagSet *pflag.FlagSet, f *pflag.Flag, val any) error {
+	sliceValue, ok := f.Value.(pflag.SliceValue)
+	if !ok {
+		// Objects of the config file, like vars, are passed as JSON object.
+		if object, ok := val.(map[string]any); ok {
+			b, err := json.Marshal(object)
+			if err != nil {
+				return fmt.Errorf("failed to encode object: %w", err)
+			}
+			val = string(b)
+		}
+
+		return flagSet.Set(f.Name, fmt.Sprintf("%v", val))
 	}
 
-	commit, err := newCommitByCommand(cmd)
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize new commit struct: %w", err)
+	var values []string
+	switch val := val.(type) {
+	case []any:
+		var err error
+		values, err = listElementStrings(val)
+		if err != nil {
+			return err
+		}
+	case []string:
+		values = val
+	case string:
+		var err error
+		values, err = splitListValue(val, !lineSeparatedFlags[f.Name])
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported type %T of list value", val)
 	}
 
-	deployTo, err := cmd.Flags().GetString(flags.DRONE_DEPLOY_TO)
+	err := sliceValue.Replace(values)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_DEPLOY_TO, err)
+		return err
 	}
+	f.Changed = true
 
-	job, err := newJobByCommand(cmd)
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize new job struct: %w", err)
+	return nil
+}
+
+// listElementStrings converts the elements of a list into strings. Scalars are
+// formatted as they are, objects and lists are encoded as JSON.
+func listElementStrings(list []any) ([]string, error) {
+	values := make([]string, 0, len(list))
+	for _, v := range list {
+		switch v.(type) {
+		case map[string]any, []any:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode list element: %w", err)
+			}
+			values = append(values, string(b))
+		default:
+			values = append(values, fmt.Sprintf("%v", v))
+		}
 	}
 
-	prev, err := newPrevByCommand(cmd)
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize new prev struct: %w", err)
+	return values, nil
+}
+
+// pluginSettingAliases are short names of plugin settings, which are known from
+// other mail plugins, for example host instead of smtp_host.
+var pluginSettingAliases = map[string][]string{
+	flags.SMTP_FROM_ADDRESS:             {"FROM"},
+	flags.SMTP_HOST:                     {"HOST"},
+	flags.SMTP_PASSWORD:                 {"PASSWORD"},
+	flags.SMTP_PORT:                     {"PORT"},
+	flags.SMTP_TLS_INSECURE_SKIP_VERIFY: {"SKIP_VERIFY"},
+	flags.SMTP_TO_ADDRESSES:             {"RECIPIENTS"},
+	flags.SMTP_USERNAME:                 {"USERNAME"},
+}
+
+// lineSeparatedFlags are list flags, whose values can contain commas, like
+// regular expressions, e.g. ^v[0-9]{1,3}, or key=value pairs. Their values are
+// only separated by newlines or passed as JSON array.
+var lineSeparatedFlags = map[string]bool{
+	flags.NOTIFY_EXCLUDE_TAGS: true,
+	flags.NOTIFY_INCLUDE_TAGS: true,
+	flags.VAR:                 true,
+}
+
+// splitListValue splits a list passed as a string, for example by an environment
+// variable. The list can be a JSON array, a single JSON object or a comma or
+// newline separated list. Commas inside double quotes or angle brackets, like in
+// `"Doe, John" <john@example.com>`, do not separate values. If commas is false,
+// only newlines separate values and values, which are no valid JSON array, like
+// the regular expression [0-9]+, are not rejected.
+func splitListValue(s string, commas bool) ([]string, error) {
+	s = strings.TrimSpace(s)
+	if len(s) <= 0 {
+		return []string{}, nil
+	}
+
+	if !commas {
+		var list []any
+		if strings.HasPrefix(s, "[") && json.Unmarshal([]byte(s), &list) == nil {
+			return listElementStrings(list)
+		}
+
+		values := make([]string, 0)
+		for _, line := range strings.Split(s, "\n") {
+			if line = strings.TrimSpace(line); len(line) > 0 {
+				values = append(values, line)
+			}
+		}
+
+		return values, nil
 	}
 
-	pullRequests, err := cmd.Flags().GetInt(flags.DRONE_PULL_REQUEST)
+	if strings.HasPrefix(s, "{") {
+		var object map[string]any
+		err := json.Unmarshal([]byte(s), &object)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode json object: %w", err)
+		}
+
+		return []string{s}, nil
+	}
+
+	if strings.HasPrefix(s, "[") {
+		var list []any
+		err := json.Unmarshal([]byte(s), &list)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode json array: %w", err)
+		}
+
+		return listElementStrings(list)
+	}
+
+	values := make([]string, 0)
+	appendValue := func(v string) {
+		if v = strings.TrimSpace(v); len(v) > 0 {
+			values = append(values, v)
+		}
+	}
+
+	quoted := false
+	angleBrackets := 0
+	start := 0
+	for i, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == '<' && !quoted:
+			angleBrackets++
+		case r == '>' && !quoted && angleBrackets > 0:
+			angleBrackets--
+		case (r == ',' || r == '\n') && !quoted && angleBrackets <= 0:
+			appendValue(s[start:i])
+			start = i + 1
+		}
+	}
+	appendValue(s[start:])
+
+	return values, nil
+}
+
+// newCIVarsByCommand returns the CI vars of the context file or the CI provider.
+// The variables of drone are defined by flags. The variables of the context file
+// and other CI systems are overridden by the flags, which have been set.
+func newCIVarsByCommand(cmd *cobra.Command) (*mail.CIVars, error) {
+	contextFile, err := cmd.Flags().GetString(flags.CONTEXT_FILE)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_PULL_REQUEST, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.CONTEXT_FILE, err)
+	}
+
+	if len(contextFile) > 0 {
+		var ciVars *mail.CIVars
+		if contextFile == "-" {
+			ciVars, err = cicontext.Read(cmd.InOrStdin())
+		} else {
+			ciVars, err = cicontext.ReadFile(contextFile)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read context file: %w", err)
+		}
+
+		err = loader.LoadChanged(cmd.Flags(), ciVars)
+		if err != nil {
+			return nil, err
+		}
+
+		return ciVars, nil
 	}
 
-	remote, err := newRemoteByCommand(cmd)
+	name, err := cmd.Flags().GetString(flags.CI_PROVIDER)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize new remote struct: %w", err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.CI_PROVIDER, err)
 	}
 
-	repo, err := newRepoByCommand(cmd)
+	p, err := provider.Lookup(name)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize new repo struct: %w", err)
+		return nil, err
+	}
+	if name == provider.NameAuto {
+		p = provider.Detect(os.Getenv)
 	}
 
-	tag, err := cmd.Flags().GetString(flags.DRONE_TAG)
+	if p == nil {
+		ciVars := new(mail.CIVars)
+		err = loader.Load(cmd.Flags(), ciVars)
+		if err != nil {
+			return nil, err
+		}
+
+		return ciVars, nil
+	}
+
+	ciVars, err := p.Load(os.Getenv)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_TAG, err)
+		return nil, fmt.Errorf("failed to load CI vars of %s: %w", p.Name(), err)
 	}
 
-	yaml, err := newYAMLByCommand(cmd)
+	err = loader.LoadChanged(cmd.Flags(), ciVars)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize new yaml struct: %w", err)
+		return nil, err
 	}
 
-	return &mail.CIVars{
-		Build:       build,
-		Commit:      commit,
-		DeployTo:    deployTo,
-		Job:         job,
-		Prev:        prev,
-		PullRequest: pullRequests,
-		Remote:      remote,
-		Repo:        repo,
-		Tag:         tag,
-		Yaml:        yaml,
-	}, nil
+	// Not every CI system provides the status as environment variable, for
+	// example GitHub Actions. Defaulting to success would hide failed builds.
+	if len(ciVars.Build.Status) <= 0 {
+		return nil, fmt.Errorf("no build status detected for %s: define it via DRONE_BUILD_STATUS", p.Name())
+	}
+
+	return ciVars, nil
 }
 
-func newBuildByCommand(cmd *cobra.Command) (*domain.Build, error) {
-	buildCreated, err := cmd.Flags().GetInt64(flags.DRONE_BUILD_CREATED)
+func newScheduleSettingsByCommand(cmd *cobra.Command) (*domain.ScheduleSettings, error) {
+	action, err := cmd.Flags().GetString(flags.SCHEDULE_ACTION)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_BUILD_CREATED, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SCHEDULE_ACTION, err)
 	}
 
-	buildEvent, err := cmd.Flags().GetString(flags.DRONE_BUILD_EVENT)
+	holidayFile, err := cmd.Flags().GetString(flags.SCHEDULE_HOLIDAY_FILE)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_BUILD_EVENT, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SCHEDULE_HOLIDAY_FILE, err)
 	}
 
-	buildFinished, err := cmd.Flags().GetInt64(flags.DRONE_BUILD_FINISHED)
+	onCallAddress, err := cmd.Flags().GetString(flags.SCHEDULE_ON_CALL_ADDRESS)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_BUILD_FINISHED, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SCHEDULE_ON_CALL_ADDRESS, err)
 	}
 
-	buildLink, err := cmd.Flags().GetString(flags.DRONE_BUILD_LINK)
+	spoolDir, err := cmd.Flags().GetString(flags.SCHEDULE_SPOOL_DIR)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_BUILD_LINK, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SCHEDULE_SPOOL_DIR, err)
 	}
 
-	buildNumber, err := cmd.Flags().GetInt(flags.DRONE_BUILD_NUMBER)
+	timezone, err := cmd.Flags().GetString(flags.SCHEDULE_TIMEZONE)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_BUILD_NUMBER, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SCHEDULE_TIMEZONE, err)
 	}
 
-	buildStared, err := cmd.Flags().GetInt64(flags.DRONE_BUILD_STARTED)
+	weekdays, err := cmd.Flags().GetStringArray(flags.SCHEDULE_WEEKDAYS)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_BUILD_STARTED, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SCHEDULE_WEEKDAYS, err)
 	}
 
-	buildStatus, err := cmd.Flags().GetString(flags.DRONE_BUILD_STATUS)
+	windows, err := cmd.Flags().GetStringArray(flags.SCHEDULE_WINDOWS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SCHEDULE_WINDOWS, err)
+	}
+
+	return &domain.ScheduleSettings{
+		Action:        action,
+		HolidayFile:   holidayFile,
+		OnCallAddress: onCallAddress,
+		SpoolDir:      spoolDir,
+		Timezone:      timezone,
+		Weekdays:      weekdays,
+		Windows:       windows,
+	}, nil
+}
+
+func newStateSettingsByCommand(cmd *cobra.Command) (*domain.StateSettings, error) {
+	file, err := cmd.Flags().GetString(flags.STATE_FILE)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_BUILD_STATUS, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.STATE_FILE, err)
 	}
 
-	build := &domain.Build{
-		Created:  buildCreated,
-		Event:    buildEvent,
-		Finished: buildFinished,
-		Link:     buildLink,
-		Number:   buildNumber,
-		Started:  buildStared,
-		Status:   buildStatus,
+	window, err := cmd.Flags().GetDuration(flags.STATE_WINDOW)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.STATE_WINDOW, err)
 	}
 
-	return build, nil
+	return &domain.StateSettings{
+		File:   file,
+		Window: window,
+	}, nil
 }
 
-func newCommitByCommand(cmd *cobra.Command) (*domain.Commit, error) {
-	authorAvatar, err := cmd.Flags().GetString(flags.DRONE_COMMIT_AUTHOR_AVATAR)
+func newSMTPSettingsByCommand(cmd *cobra.Command) (*domain.SMTPSettings, error) {
+	smtpStartTLS, err := cmd.Flags().GetBool(flags.SMTP_START_TLS)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_COMMIT_AUTHOR_AVATAR, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_START_TLS, err)
 	}
 
-	authorEmail, err := cmd.Flags().GetString(flags.DRONE_COMMIT_AUTHOR_EMAIL)
+	smtpFromAddress, err := cmd.Flags().GetString(flags.SMTP_FROM_ADDRESS)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_COMMIT_AUTHOR_EMAIL, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_FROM_ADDRESS, err)
 	}
 
-	authorName, err := cmd.Flags().GetString(flags.DRONE_COMMIT_AUTHOR_NAME)
+	smtpFromName, err := cmd.Flags().GetString(flags.SMTP_FROM_NAME)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_COMMIT_AUTHOR_NAME, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_FROM_NAME, err)
 	}
 
-	branch, err := cmd.Flags().GetString(flags.DRONE_COMMIT_BRANCH)
+	smtpHELOName, err := cmd.Flags().GetString(flags.SMTP_HELO)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_COMMIT_BRANCH, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_HELO, err)
 	}
 
-	link, err := cmd.Flags().GetString(flags.DRONE_COMMIT_LINK)
+	smtpHost, err := cmd.Flags().GetString(flags.SMTP_HOST)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_COMMIT_LINK, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_HOST, err)
 	}
 
-	message, err := cmd.Flags().GetString(flags.DRONE_COMMIT_MESSAGE)
+	smtpListUnsubscribe, err := cmd.Flags().GetString(flags.SMTP_LIST_UNSUBSCRIBE)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_COMMIT_MESSAGE, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_LIST_UNSUBSCRIBE, err)
 	}
 
-	ref, err := cmd.Flags().GetString(flags.DRONE_COMMIT_REF)
+	smtpPassword, err := cmd.Flags().GetString(flags.SMTP_PASSWORD)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_COMMIT_REF, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_PASSWORD, err)
 	}
 
-	sha, err := cmd.Flags().GetString(flags.DRONE_COMMIT_SHA)
+	smtpPort, err := cmd.Flags().GetInt(flags.SMTP_PORT)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_COMMIT_SHA, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_PORT, err)
 	}
 
-	commit := &domain.Commit{
-		Author: &domain.Author{
-			Avatar: authorAvatar,
-			Email:  authorEmail,
-			Name:   authorName,
-		},
-		Branch:  branch,
-		Link:    link,
-		Message: message,
-		Ref:     ref,
-		Sha:     sha,
+	smtpTLSInsecureSkipVerify, err := cmd.Flags().GetBool(flags.SMTP_TLS_INSECURE_SKIP_VERIFY)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_TLS_INSECURE_SKIP_VERIFY, err)
 	}
 
-	return commit, nil
+	smtpUsername, err := cmd.Flags().GetString(flags.SMTP_USERNAME)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_USERNAME, err)
+	}
+
+	return &domain.SMTPSettings{
+		FromAddress:           smtpFromAddress,
+		FromName:              smtpFromName,
+		HELOName:              smtpHELOName,
+		Host:                  smtpHost,
+		ListUnsubscribe:       smtpListUnsubscribe,
+		Password:              smtpPassword,
+		Port:                  smtpPort,
+		StartTLS:              smtpStartTLS,
+		TLSInsecureSkipVerify: smtpTLSInsecureSkipVerify,
+		Username:              smtpUsername,
+	}, nil
 }
 
-func newJobByCommand(cmd *cobra.Command) (*domain.Job, error) {
-	exitCode, err := cmd.Flags().GetInt(flags.DRONE_JOB_EXIT_CODE)
+func newDeploymentSettingsByCommand(cmd *cobra.Command) (*domain.DeploymentSettings, error) {
+	historyFile, err := cmd.Flags().GetString(flags.DEPLOY_HISTORY_FILE)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_JOB_EXIT_CODE, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DEPLOY_HISTORY_FILE, err)
 	}
 
-	finished, err := cmd.Flags().GetInt64(flags.DRONE_BUILD_FINISHED)
+	recipientValues, err := cmd.Flags().GetStringArray(flags.DEPLOY_RECIPIENTS)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_BUILD_FINISHED, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DEPLOY_RECIPIENTS, err)
 	}
 
-	started, err := cmd.Flags().GetInt64(flags.DRONE_BUILD_STARTED)
-	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_BUILD_STARTED, err)
+	recipients := make([]*domain.DeploymentRecipients, 0, len(recipientValues))
+	for _, recipientValue := range recipientValues {
+		deploymentRecipients := new(domain.DeploymentRecipients)
+		err = json.Unmarshal([]byte(recipientValue), deploymentRecipients)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode value %s of %s: %w", recipientValue, flags.DEPLOY_RECIPIENTS, err)
+		}
+		recipients = append(recipients, deploymentRecipients)
 	}
 
-	status, err := cmd.Flags().GetString(flags.DRONE_JOB_STATUS)
+	return &domain.DeploymentSettings{
+		HistoryFile: historyFile,
+		Recipients:  recipients,
+	}, nil
+}
+
+func newDigestSettingsByCommand(cmd *cobra.Command) (*domain.DigestSettings, error) {
+	mode, err := cmd.Flags().GetString(flags.NOTIFY_MODE)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_JOB_STATUS, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.NOTIFY_MODE, err)
 	}
 
-	job := &domain.Job{
-		ExitCode: exitCode,
-		Finished: finished,
-		Started:  started,
-		Status:   status,
+	spool, err := cmd.Flags().GetString(flags.DIGEST_SPOOL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DIGEST_SPOOL, err)
 	}
 
-	return job, nil
+	return &domain.DigestSettings{
+		Mode:  mode,
+		Spool: spool,
+	}, nil
 }
 
-func newPrevByCommand(cmd *cobra.Command) (*domain.Prev, error) {
-	prevBuildNumber, err := cmd.Flags().GetInt(flags.DRONE_PREV_BUILD_NUMBER)
+func newDroneAPISettingsByCommand(cmd *cobra.Command) (*domain.DroneAPISettings, error) {
+	logLines, err := cmd.Flags().GetInt(flags.DRONE_LOG_LINES)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_PREV_BUILD_NUMBER, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_LOG_LINES, err)
 	}
 
-	prevBuildStatus, err := cmd.Flags().GetString(flags.DRONE_PREV_BUILD_STATUS)
+	server, err := cmd.Flags().GetString(flags.DRONE_SERVER)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_PREV_BUILD_STATUS, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_SERVER, err)
 	}
 
-	prevCommitSha, err := cmd.Flags().GetString(flags.DRONE_PREV_COMMIT_SHA)
+	token, err := cmd.Flags().GetString(flags.DRONE_TOKEN)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_PREV_COMMIT_SHA, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_TOKEN, err)
 	}
 
-	prev := &domain.Prev{
-		Build: &domain.PrevBuild{
-			Number: prevBuildNumber,
-			Status: prevBuildStatus,
-		},
-		Commit: &domain.PrevCommit{
-			Sha: prevCommitSha,
-		},
+	return &domain.DroneAPISettings{
+		LogLines: logLines,
+		Server:   server,
+		Token:    token,
+	}, nil
+}
+
+func newDryRunSettingsByCommand(cmd *cobra.Command) (*domain.DryRunSettings, error) {
+	enabled, err := cmd.Flags().GetBool(flags.DRY_RUN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRY_RUN, err)
+	}
+
+	smtp, err := cmd.Flags().GetBool(flags.DRY_RUN_SMTP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRY_RUN_SMTP, err)
 	}
 
-	return prev, nil
+	return &domain.DryRunSettings{
+		Enabled: enabled,
+		SMTP:    smtp,
+	}, nil
 }
 
-func newRemoteByCommand(cmd *cobra.Command) (*domain.Remote, error) {
-	remoteURL, err := cmd.Flags().GetString(flags.DRONE_REMOTE_URL)
+func newEscalationSettingsByCommand(cmd *cobra.Command) (*domain.EscalationSettings, error) {
+	historyFile, err := cmd.Flags().GetString(flags.ESCALATION_HISTORY_FILE)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.ESCALATION_HISTORY_FILE, err)
+	}
+
+	levelValues, err := cmd.Flags().GetStringArray(flags.ESCALATION_LEVELS)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_REMOTE_URL, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.ESCALATION_LEVELS, err)
 	}
 
-	remote := &domain.Remote{
-		URL: remoteURL,
+	levels := make([]*domain.EscalationLevel, 0, len(levelValues))
+	for _, levelValue := range levelValues {
+		level := new(domain.EscalationLevel)
+		err = json.Unmarshal([]byte(levelValue), level)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode value %s of %s: %w", levelValue, flags.ESCALATION_LEVELS, err)
+		}
+		levels = append(levels, level)
 	}
 
-	return remote, nil
+	return &domain.EscalationSettings{
+		HistoryFile: historyFile,
+		Levels:      levels,
+	}, nil
 }
 
-func newRepoByCommand(cmd *cobra.Command) (*domain.Repo, error) {
-	avatar, err := cmd.Flags().GetString(flags.DRONE_REPO_AVATAR)
+func newFilterSettingsByCommand(cmd *cobra.Command) (*domain.FilterSettings, error) {
+	condition, err := cmd.Flags().GetString(flags.NOTIFY_CONDITION)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_REPO_AVATAR, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.NOTIFY_CONDITION, err)
 	}
 
-	branch, err := cmd.Flags().GetString(flags.DRONE_REPO_BRANCH)
+	branches, err := newFilterByCommand(cmd, flags.NOTIFY_INCLUDE_BRANCHES, flags.NOTIFY_EXCLUDE_BRANCHES)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_REPO_BRANCH, err)
+		return nil, err
 	}
 
-	fullName, err := cmd.Flags().GetString(flags.DRONE_REPO_NAME)
+	deployTo, err := newFilterByCommand(cmd, flags.NOTIFY_INCLUDE_DEPLOY_TO, flags.NOTIFY_EXCLUDE_DEPLOY_TO)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_REPO_NAME, err)
+		return nil, err
 	}
 
-	link, err := cmd.Flags().GetString(flags.DRONE_REPO_LINK)
+	events, err := newFilterByCommand(cmd, flags.NOTIFY_INCLUDE_EVENTS, flags.NOTIFY_EXCLUDE_EVENTS)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_REPO_LINK, err)
+		return nil, err
 	}
 
-	name, err := cmd.Flags().GetString(flags.DRONE_REPO)
+	repos, err := newFilterByCommand(cmd, flags.NOTIFY_INCLUDE_REPOS, flags.NOTIFY_EXCLUDE_REPOS)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_REPO, err)
+		return nil, err
 	}
 
-	owner, err := cmd.Flags().GetString(flags.DRONE_REPO_OWNER)
+	statuses, err := newFilterByCommand(cmd, flags.NOTIFY_INCLUDE_STATUSES, flags.NOTIFY_EXCLUDE_STATUSES)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_REPO_OWNER, err)
+		return nil, err
 	}
 
-	private, err := cmd.Flags().GetBool(flags.DRONE_REPO_PRIVATE)
+	tags, err := newFilterByCommand(cmd, flags.NOTIFY_INCLUDE_TAGS, flags.NOTIFY_EXCLUDE_TAGS)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_REPO_PRIVATE, err)
+		return nil, err
 	}
 
-	scm, err := cmd.Flags().GetString(flags.DRONE_REPO_SCM)
+	when, err := cmd.Flags().GetString(flags.NOTIFY_WHEN)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_REPO_SCM, err)
-	}
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.NOTIFY_WHEN, err)
+	}
+
+	return &domain.FilterSettings{
+		Branches:  branches,
+		Condition: condition,
+		DeployTo:  deployTo,
+		Events:    events,
+		Repos:     repos,
+		Statuses:  statuses,
+		Tags:      tags,
+		When:      when,
+	}, nil
+}
 
-	trusted, err := cmd.Flags().GetBool(flags.DRONE_REPO_TRUSTED)
+func newFilterByCommand(cmd *cobra.Command, includeFlag string, excludeFlag string) (*domain.Filter, error) {
+	exclude, err := cmd.Flags().GetStringArray(excludeFlag)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_REPO_TRUSTED, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", excludeFlag, err)
 	}
 
-	remote := &domain.Repo{
-		Avatar:   avatar,
-		Branch:   branch,
-		FullName: fullName,
-		Link:     link,
-		Name:     name,
-		Owner:    owner,
-		Private:  private,
-		SCM:      scm,
-		Trusted:  trusted,
+	include, err := cmd.Flags().GetStringArray(includeFlag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect value of %s: %w", includeFlag, err)
 	}
 
-	return remote, nil
+	return &domain.Filter{
+		Exclude: exclude,
+		Include: include,
+	}, nil
 }
 
-func newYAMLByCommand(cmd *cobra.Command) (*domain.Yaml, error) {
-	signed, err := cmd.Flags().GetBool(flags.DRONE_YAML_SIGNED)
+// newRecipientPolicySettingsByCommand returns the recipient settings of the
+// persistent flags, which are shared by all commands sending mails.
+func newRecipientPolicySettingsByCommand(cmd *cobra.Command) (*domain.RecipientSettings, error) {
+	allowList, err := cmd.Flags().GetStringArray(flags.SMTP_ALLOW_LIST)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_ALLOW_LIST, err)
+	}
+
+	allowListPrivateOnly, err := cmd.Flags().GetBool(flags.SMTP_ALLOW_LIST_PRIVATE_ONLY)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_ALLOW_LIST_PRIVATE_ONLY, err)
+	}
+
+	denyList, err := cmd.Flags().GetStringArray(flags.SMTP_DENY_LIST)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_YAML_SIGNED, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_DENY_LIST, err)
 	}
 
-	verified, err := cmd.Flags().GetBool(flags.DRONE_YAML_VERIFIED)
+	directoryFile, err := cmd.Flags().GetString(flags.SMTP_DIRECTORY_FILE)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DRONE_YAML_VERIFIED, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_DIRECTORY_FILE, err)
 	}
 
-	yaml := &domain.Yaml{
-		Signed:   signed,
-		Verified: verified,
+	fallbackAddress, err := cmd.Flags().GetString(flags.SMTP_FALLBACK_ADDRESS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_FALLBACK_ADDRESS, err)
+	}
+
+	optOutFile, err := cmd.Flags().GetString(flags.SMTP_OPT_OUT_FILE)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_OPT_OUT_FILE, err)
 	}
 
-	return yaml, nil
+	return &domain.RecipientSettings{
+		AllowList:            allowList,
+		AllowListPrivateOnly: allowListPrivateOnly,
+		DenyList:             denyList,
+		DirectoryFile:        directoryFile,
+		FallbackAddress:      fallbackAddress,
+		OptOutFile:           optOutFile,
+	}, nil
 }
 
-func newSMTPSettingsByCommand(cmd *cobra.Command) (*domain.SMTPSettings, error) {
-	smtpStartTLS, err := cmd.Flags().GetBool(flags.SMTP_START_TLS)
+func newRecipientSettingsByCommand(cmd *cobra.Command) (*domain.RecipientSettings, error) {
+	recipientSettings, err := newRecipientPolicySettingsByCommand(cmd)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_START_TLS, err)
+		return nil, err
 	}
 
-	smtpFromAddress, err := cmd.Flags().GetString(flags.SMTP_FROM_ADDRESS)
+	coAuthors, err := cmd.Flags().GetBool(flags.SMTP_TO_CO_AUTHORS)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_FROM_ADDRESS, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_TO_CO_AUTHORS, err)
 	}
 
-	smtpFromName, err := cmd.Flags().GetString(flags.SMTP_FROM_NAME)
+	conditionalValues, err := cmd.Flags().GetStringArray(flags.SMTP_TO_CONDITIONAL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_FROM_NAME, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_TO_CONDITIONAL, err)
 	}
 
-	smtpHELOName, err := cmd.Flags().GetString(flags.SMTP_HELO)
+	conditional := make([]*domain.ConditionalRecipient, 0, len(conditionalValues))
+	for _, conditionalValue := range conditionalValues {
+		conditionalRecipient := new(domain.ConditionalRecipient)
+		err = json.Unmarshal([]byte(conditionalValue), conditionalRecipient)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode value %s of %s: %w", conditionalValue, flags.SMTP_TO_CONDITIONAL, err)
+		}
+		conditional = append(conditional, conditionalRecipient)
+	}
+
+	pullRequestAuthor, err := cmd.Flags().GetBool(flags.SMTP_TO_PULL_REQUEST_AUTHOR)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_HELO, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_TO_PULL_REQUEST_AUTHOR, err)
 	}
 
-	smtpHost, err := cmd.Flags().GetString(flags.SMTP_HOST)
+	trailers, err := cmd.Flags().GetStringArray(flags.SMTP_TO_TRAILERS)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_HOST, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_TO_TRAILERS, err)
 	}
 
-	smtpPassword, err := cmd.Flags().GetString(flags.SMTP_PASSWORD)
+	recipientSettings.CoAuthors = coAuthors
+	recipientSettings.Conditional = conditional
+	recipientSettings.PullRequestAuthor = pullRequestAuthor
+	recipientSettings.Trailers = trailers
+
+	return recipientSettings, nil
+}
+
+func newTemplateSettingsByCommand(cmd *cobra.Command) (*domain.TemplateSettings, error) {
+	dataFiles, err := cmd.Flags().GetStringArray(flags.DATA_FILE)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_PASSWORD, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.DATA_FILE, err)
 	}
 
-	smtpPort, err := cmd.Flags().GetInt(flags.SMTP_PORT)
+	varsValue, err := cmd.Flags().GetString(flags.VARS)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_PORT, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.VARS, err)
 	}
 
-	smtpTLSInsecureSkipVerify, err := cmd.Flags().GetBool(flags.SMTP_TLS_INSECURE_SKIP_VERIFY)
+	// JSON objects are valid YAML documents.
+	vars := make(map[string]any)
+	err = yaml.Unmarshal([]byte(varsValue), &vars)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_TLS_INSECURE_SKIP_VERIFY, err)
+		return nil, fmt.Errorf("failed to decode value of %s: %w", flags.VARS, err)
 	}
 
-	smtpUsername, err := cmd.Flags().GetString(flags.SMTP_USERNAME)
+	varValues, err := cmd.Flags().GetStringArray(flags.VAR)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_USERNAME, err)
+		return nil, fmt.Errorf("failed to detect value of %s: %w", flags.VAR, err)
 	}
 
-	return &domain.SMTPSettings{
-		FromAddress:           smtpFromAddress,
-		FromName:              smtpFromName,
-		HELOName:              smtpHELOName,
-		Host:                  smtpHost,
-		Password:              smtpPassword,
-		Port:                  smtpPort,
-		StartTLS:              smtpStartTLS,
-		TLSInsecureSkipVerify: smtpTLSInsecureSkipVerify,
-		Username:              smtpUsername,
+	// Variables of --var override the variables of --vars. Keys separated by dots
+	// define nested variables, e.g. image.tag=1.2.3 is available as
+	// .Vars.image.tag.
+	for _, varValue := range varValues {
+		key, value, ok := strings.Cut(varValue, "=")
+		if !ok || len(key) <= 0 {
+			return nil, fmt.Errorf("failed to decode value %s of %s: expected key=value", varValue, flags.VAR)
+		}
+
+		setNestedValue(vars, strings.Split(key, "."), value)
+	}
+
+	return &domain.TemplateSettings{
+		DataFiles: dataFiles,
+		Vars:      vars,
 	}, nil
 }
+
+// setNestedValue sets the value of the map m by the path of keys. Missing maps
+// on the way to the value are created, other values on the way are replaced.
+func setNestedValue(m map[string]any, keys []string, value any) {
+	for _, key := range keys[:len(keys)-1] {
+		nested, ok := m[key].(map[string]any)
+		if !ok {
+			nested = make(map[string]any)
+			m[key] = nested
+		}
+		m = nested
+	}
+
+	m[keys[len(keys)-1]] = value
+}
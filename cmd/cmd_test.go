@@ -0,0 +1,359 @@
+package cmd
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/flags"
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/loader"
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/mail"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+func newTestCommand(t *testing.T) *cobra.Command {
+	t.Helper()
+
+	cmd := &cobra.Command{Use: "test"}
+	cmd.Flags().String(flags.SMTP_HOST, "localhost", "")
+	cmd.Flags().StringArray(flags.NOTIFY_INCLUDE_TAGS, []string{}, "")
+	cmd.Flags().StringArray(flags.SMTP_TO_ADDRESSES, []string{}, "")
+	cmd.Flags().StringArray(flags.VAR, []string{}, "")
+
+	return cmd
+}
+
+func TestBindFlags(t *testing.T) {
+	testCases := []struct {
+		name     string
+		env      map[string]string
+		config   string
+		args     []string
+		flag     string
+		expected []string
+	}{
+		{
+			name:     "env",
+			env:      map[string]string{"SMTP_HOST": "smtp.example.com"},
+			flag:     flags.SMTP_HOST,
+			expected: []string{"smtp.example.com"},
+		},
+		{
+			name:     "plugin env",
+			env:      map[string]string{"PLUGIN_SMTP_HOST": "plugin.example.com"},
+			flag:     flags.SMTP_HOST,
+			expected: []string{"plugin.example.com"},
+		},
+		{
+			name: "plugin env takes precedence over env",
+			env: map[string]string{
+				"PLUGIN_SMTP_HOST": "plugin.example.com",
+				"SMTP_HOST":        "smtp.example.com",
+			},
+			flag:     flags.SMTP_HOST,
+			expected: []string{"plugin.example.com"},
+		},
+		{
+			name:     "plugin alias",
+			env:      map[string]string{"PLUGIN_HOST": "alias.example.com"},
+			flag:     flags.SMTP_HOST,
+			expected: []string{"alias.example.com"},
+		},
+		{
+			name: "plugin env takes precedence over plugin alias",
+			env: map[string]string{
+				"PLUGIN_HOST":      "alias.example.com",
+				"PLUGIN_SMTP_HOST": "plugin.example.com",
+			},
+			flag:     flags.SMTP_HOST,
+			expected: []string{"plugin.example.com"},
+		},
+		{
+			name: "plugin alias takes precedence over env",
+			env: map[string]string{
+				"PLUGIN_HOST": "alias.example.com",
+				"SMTP_HOST":   "smtp.example.com",
+			},
+			flag:     flags.SMTP_HOST,
+			expected: []string{"alias.example.com"},
+		},
+		{
+			name:     "plugin alias of list",
+			env:      map[string]string{"PLUGIN_RECIPIENTS": `["max@example.com","erika@example.com"]`},
+			flag:     flags.SMTP_TO_ADDRESSES,
+			expected: []string{"max@example.com", "erika@example.com"},
+		},
+		{
+			name:     "env takes precedence over config",
+			env:      map[string]string{"SMTP_HOST": "smtp.example.com"},
+			config:   "smtp-host: config.example.com\n",
+			flag:     flags.SMTP_HOST,
+			expected: []string{"smtp.example.com"},
+		},
+		{
+			name:     "flag takes precedence over env",
+			env:      map[string]string{"PLUGIN_SMTP_HOST": "plugin.example.com"},
+			args:     []string{"--smtp-host", "flag.example.com"},
+			flag:     flags.SMTP_HOST,
+			expected: []string{"flag.example.com"},
+		},
+		{
+			name:     "comma list",
+			env:      map[string]string{"SMTP_TO_ADDRESSES": `max@example.com, "Mustermann, Erika" <erika@example.com>`},
+			flag:     flags.SMTP_TO_ADDRESSES,
+			expected: []string{"max@example.com", `"Mustermann, Erika" <erika@example.com>`},
+		},
+		{
+			name:     "newline list",
+			env:      map[string]string{"SMTP_TO_ADDRESSES": "max@example.com\nerika@example.com\n"},
+			flag:     flags.SMTP_TO_ADDRESSES,
+			expected: []string{"max@example.com", "erika@example.com"},
+		},
+		{
+			name:     "json array",
+			env:      map[string]string{"PLUGIN_SMTP_TO_ADDRESSES": `["max@example.com", "erika@example.com"]`},
+			flag:     flags.SMTP_TO_ADDRESSES,
+			expected: []string{"max@example.com", "erika@example.com"},
+		},
+		{
+			name:     "yaml list",
+			config:   "smtp-to-addresses:\n- max@example.com\n- erika@example.com\n",
+			flag:     flags.SMTP_TO_ADDRESSES,
+			expected: []string{"max@example.com", "erika@example.com"},
+		},
+		{
+			name:     "regular expression with comma",
+			env:      map[string]string{"NOTIFY_INCLUDE_TAGS": "^v[0-9]{1,3}\n[0-9]+-rc"},
+			flag:     flags.NOTIFY_INCLUDE_TAGS,
+			expected: []string{"^v[0-9]{1,3}", "[0-9]+-rc"},
+		},
+		{
+			name:     "regular expressions as json array",
+			env:      map[string]string{"NOTIFY_INCLUDE_TAGS": `["^v[0-9]{1,3}", "^release-"]`},
+			flag:     flags.NOTIFY_INCLUDE_TAGS,
+			expected: []string{"^v[0-9]{1,3}", "^release-"},
+		},
+		{
+			name:     "key value pair with comma",
+			env:      map[string]string{"VAR": "msg=a,b"},
+			flag:     flags.VAR,
+			expected: []string{"msg=a,b"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			for key, value := range testCase.env {
+				t.Setenv(key, value)
+			}
+
+			cmd := newTestCommand(t)
+			err := cmd.Flags().Parse(testCase.args)
+			if err != nil {
+				t.Fatalf("failed to parse args: %v", err)
+			}
+
+			v := viper.New()
+			if len(testCase.config) > 0 {
+				v.SetConfigType("yaml")
+				err = v.ReadConfig(strings.NewReader(testCase.config))
+				if err != nil {
+					t.Fatalf("failed to read config: %v", err)
+				}
+			}
+
+			err = bindFlags(cmd, v)
+			if err != nil {
+				t.Fatalf("failed to bind flags: %v", err)
+			}
+
+			var actual []string
+			if testCase.flag == flags.SMTP_HOST {
+				value, err := cmd.Flags().GetString(testCase.flag)
+				if err != nil {
+					t.Fatalf("failed to get flag: %v", err)
+				}
+				actual = []string{value}
+			} else {
+				actual, err = cmd.Flags().GetStringArray(testCase.flag)
+				if err != nil {
+					t.Fatalf("failed to get flag: %v", err)
+				}
+			}
+
+			if !slices.Equal(actual, testCase.expected) {
+				t.Errorf("expected %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+// TestBindFlagsCIVars sets all CI variables by their environment variables and
+// loads them through viper like the root command.
+func TestBindFlagsCIVars(t *testing.T) {
+	cmd := &cobra.Command{Use: "test"}
+	err := loader.AddFlags(cmd.Flags(), &mail.CIVars{})
+	if err != nil {
+		t.Fatalf("failed to add flags: %v", err)
+	}
+
+	expected := make(map[string][]string)
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		name := strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+
+		var values []string
+		switch f.Value.Type() {
+		case "bool":
+			values = []string{strconv.FormatBool(f.DefValue != "true")}
+		case "int", "int64":
+			values = []string{"42"}
+		case "stringArray":
+			values = []string{strings.ToLower(name) + "-1", strings.ToLower(name) + "-2"}
+		default:
+			values = []string{strings.ToLower(name)}
+		}
+
+		t.Setenv(name, strings.Join(values, "\n"))
+		expected[f.Name] = values
+	})
+
+	t.Setenv("PLUGIN_DRONE_BUILD_STATUS", "failure")
+	expected["drone-build-status"] = []string{"failure"}
+
+	err = bindFlags(cmd, viper.New())
+	if err != nil {
+		t.Fatalf("failed to bind flags: %v", err)
+	}
+
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		actual := []string{f.Value.String()}
+		if sliceValue, ok := f.Value.(pflag.SliceValue); ok {
+			actual = sliceValue.GetSlice()
+		}
+		if !slices.Equal(actual, expected[f.Name]) {
+			t.Errorf("expected %q of %s, got %q", expected[f.Name], f.Name, actual)
+		}
+	})
+
+	ciVars := new(mail.CIVars)
+	err = loader.Load(cmd.Flags(), ciVars)
+	if err != nil {
+		t.Fatalf("failed to load flags: %v", err)
+	}
+
+	if ciVars.Build.Status != "failure" {
+		t.Errorf("expected build status of PLUGIN_DRONE_BUILD_STATUS, got %s", ciVars.Build.Status)
+	}
+	if ciVars.Build.Number != 42 {
+		t.Errorf("expected build number of DRONE_BUILD_NUMBER, got %d", ciVars.Build.Number)
+	}
+	if ciVars.Repo.FullName != "drone_repo" {
+		t.Errorf("expected repo full name of DRONE_REPO, got %s", ciVars.Repo.FullName)
+	}
+	if ciVars.Repo.Private {
+		t.Error("expected public repository of DRONE_REPO_PRIVATE")
+	}
+	if ciVars.Commit.Author.Username != "drone_commit_author" {
+		t.Errorf("expected commit author username of DRONE_COMMIT_AUTHOR, got %s", ciVars.Commit.Author.Username)
+	}
+	if expected := []string{"drone_failed_steps-1", "drone_failed_steps-2"}; !slices.Equal(ciVars.FailedSteps, expected) {
+		t.Errorf("expected failed steps %q of DRONE_FAILED_STEPS, got %q", expected, ciVars.FailedSteps)
+	}
+}
+
+func TestNewTemplateSettingsByCommand(t *testing.T) {
+	testCases := []struct {
+		name        string
+		env         map[string]string
+		args        []string
+		expected    map[string]any
+		expectedErr bool
+	}{
+		{
+			name:     "nested var",
+			args:     []string{"--var", "image.tag=1.2.3", "--var", "image.name=drone-email"},
+			expected: map[string]any{"image": map[string]any{"name": "drone-email", "tag": "1.2.3"}},
+		},
+		{
+			name: "var overrides vars",
+			args: []string{"--vars", `{"image": {"tag": "1.0.0", "name": "drone-email"}, "env": "prod"}`, "--var", "image.tag=1.2.3"},
+			expected: map[string]any{
+				"env":   "prod",
+				"image": map[string]any{"name": "drone-email", "tag": "1.2.3"},
+			},
+		},
+		{
+			name:     "var replaces value on the way",
+			args:     []string{"--vars", "image: drone-email", "--var", "image.tag=1.2.3"},
+			expected: map[string]any{"image": map[string]any{"tag": "1.2.3"}},
+		},
+		{
+			name:     "vars as json object of plugin env",
+			env:      map[string]string{"PLUGIN_VARS": `{"owner": "max", "replicas": 3}`},
+			expected: map[string]any{"owner": "max", "replicas": 3},
+		},
+		{
+			name:        "var without equal sign",
+			args:        []string{"--var", "image.tag"},
+			expectedErr: true,
+		},
+		{
+			name:        "var without key",
+			args:        []string{"--var", "=1.2.3"},
+			expectedErr: true,
+		},
+		{
+			name:        "vars without object",
+			args:        []string{"--vars", "[1, 2]"},
+			expectedErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			for key, value := range testCase.env {
+				t.Setenv(key, value)
+			}
+
+			cmd := &cobra.Command{Use: "test"}
+			cmd.Flags().StringArray(flags.DATA_FILE, []string{}, "")
+			cmd.Flags().StringArray(flags.VAR, []string{}, "")
+			cmd.Flags().String(flags.VARS, "", "")
+
+			err := cmd.Flags().Parse(testCase.args)
+			if err != nil {
+				t.Fatalf("failed to parse args: %v", err)
+			}
+
+			err = bindFlags(cmd, viper.New())
+			if err != nil {
+				t.Fatalf("failed to bind flags: %v", err)
+			}
+
+			templateSettings, err := newTemplateSettingsByCommand(cmd)
+			switch {
+			case testCase.expectedErr && err == nil:
+				t.Fatalf("expected an error, got %v", templateSettings.Vars)
+			case testCase.expectedErr:
+				return
+			case err != nil:
+				t.Fatalf("failed to create template settings: %v", err)
+			}
+
+			if !reflect.DeepEqual(templateSettings.Vars, testCase.expected) {
+				t.Errorf("expected %v, got %v", testCase.expected, templateSettings.Vars)
+			}
+		})
+	}
+}
+
+func TestSplitListValueInvalidJSON(t *testing.T) {
+	_, err := splitListValue(`["max@example.com"`, true)
+	if err == nil {
+		t.Error("expected an error of an invalid JSON array")
+	}
+}
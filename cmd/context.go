@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/cicontext"
+	"github.com/spf13/cobra"
+)
+
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Inspect the CI context read by --context-file",
+}
+
+var contextSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of the CI context",
+	Long: `Print the JSON Schema of the JSON or YAML documents, which are read by
+--context-file.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		b, err := cicontext.Schema()
+		if err != nil {
+			return err
+		}
+
+		_, err = os.Stdout.Write(b)
+		if err != nil {
+			return fmt.Errorf("failed to write schema on stdout: %w", err)
+		}
+
+		return nil
+	},
+}
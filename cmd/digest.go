@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/domain"
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/flags"
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/mail"
+	"github.com/spf13/cobra"
+)
+
+var digestCmd = &cobra.Command{
+	Use:   "digest",
+	Short: "Manage the digest of queued builds",
+	Long: `Manage the digest of builds, which have been queued to the digest spool
+instead of sending mails, because the notify mode is digest.`,
+}
+
+var digestSendCmd = &cobra.Command{
+	Use:   "send",
+	Short: "Send a summary of all queued builds",
+	Long: `Send a summary of all builds of the digest spool, grouped by repository and
+branch, to the recipients. The builds are removed from the digest spool
+afterwards.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		smtpSettings, err := newSMTPSettingsByCommand(cmd)
+		if err != nil {
+			return fmt.Errorf("failed to initialize new config vars: %w", err)
+		}
+
+		spool, err := cmd.Flags().GetString(flags.DIGEST_SPOOL)
+		if err != nil {
+			return fmt.Errorf("failed to detect value of %s: %w", flags.DIGEST_SPOOL, err)
+		}
+
+		if len(spool) <= 0 {
+			return fmt.Errorf("no digest spool defined via %s", flags.DIGEST_SPOOL)
+		}
+
+		recipientSettings, err := newRecipientPolicySettingsByCommand(cmd)
+		if err != nil {
+			return fmt.Errorf("failed to initialize new recipient settings: %w", err)
+		}
+
+		recipients, err := cmd.Flags().GetStringArray(flags.SMTP_TO_ADDRESSES)
+		if err != nil {
+			return fmt.Errorf("failed to detect value of %s: %w", flags.SMTP_TO_ADDRESSES, err)
+		}
+
+		dryRunSettings, err := newDryRunSettingsByCommand(cmd)
+		if err != nil {
+			return fmt.Errorf("failed to initialize new dry run settings: %w", err)
+		}
+
+		templateSettings, err := newTemplateSettingsByCommand(cmd)
+		if err != nil {
+			return fmt.Errorf("failed to initialize new template settings: %w", err)
+		}
+
+		plugin := mail.NewPlugin(&mail.Settings{
+			Digest:    &domain.DigestSettings{Spool: spool},
+			DryRun:    dryRunSettings,
+			Recipient: recipientSettings,
+			SMTP:      smtpSettings,
+			Template:  templateSettings,
+		})
+
+		n, err := plugin.SendDigest(cmd.Context(), recipients)
+		skipErr := new(mail.SkipError)
+		switch {
+		case errors.As(err, &skipErr):
+			_, err = fmt.Fprintf(os.Stdout, "E-Mails skipped: %s", skipErr.Reason)
+			if err != nil {
+				return fmt.Errorf("failed to write message on stdout: %w", err)
+			}
+			return nil
+		case err != nil:
+			return applyFailurePolicy(cmd, fmt.Errorf("failed to send digest: %w", err))
+		}
+
+		_, err = fmt.Fprintf(os.Stdout, "Digest of %d builds successfully sent", n)
+		if err != nil {
+			return fmt.Errorf("failed to write message on stdout: %w", err)
+		}
+
+		return nil
+	},
+}
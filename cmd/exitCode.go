@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/domain"
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/flags"
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/mail"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of the command. Errors, which are neither rendering nor delivery
+// errors, are considered as configuration errors, for example invalid flags,
+// settings or unreadable files.
+const (
+	ExitCodeSuccess  = 0
+	ExitCodeConfig   = 2
+	ExitCodeRender   = 3
+	ExitCodeDelivery = 4
+)
+
+// ExitCode returns the exit code of the error returned by Execute.
+func ExitCode(err error) int {
+	var deliveryErr *mail.DeliveryError
+	var renderErr *mail.RenderError
+
+	switch {
+	case err == nil:
+		return ExitCodeSuccess
+	case errors.As(err, &renderErr):
+		return ExitCodeRender
+	case errors.As(err, &deliveryErr):
+		return ExitCodeDelivery
+	default:
+		return ExitCodeConfig
+	}
+}
+
+// applyFailurePolicy applies the failure policy to delivery errors. The policies
+// warn and ignore discard the error. A warning is written on stderr for the
+// policy warn. All other errors are returned unchanged.
+func applyFailurePolicy(cmd *cobra.Command, err error) error {
+	deliveryErr := new(mail.DeliveryError)
+	if !errors.As(err, &deliveryErr) {
+		return err
+	}
+
+	policy, flagErr := cmd.Flags().GetString(flags.FAILURE_POLICY)
+	if flagErr != nil {
+		return errors.Join(err, fmt.Errorf("failed to detect value of %s: %w", flags.FAILURE_POLICY, flagErr))
+	}
+
+	switch policy {
+	case domain.FailurePolicyWarn:
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return nil
+	case domain.FailurePolicyIgnore:
+		return nil
+	default:
+		return err
+	}
+}
+
+// validateFailurePolicy returns an error if the failure policy is not
+// supported.
+func validateFailurePolicy(cmd *cobra.Command) error {
+	policy, err := cmd.Flags().GetString(flags.FAILURE_POLICY)
+	if err != nil {
+		return fmt.Errorf("failed to detect value of %s: %w", flags.FAILURE_POLICY, err)
+	}
+
+	switch policy {
+	case domain.FailurePolicyFail, domain.FailurePolicyIgnore, domain.FailurePolicyWarn:
+		return nil
+	default:
+		return fmt.Errorf("unsupported value %s of %s: expected fail, warn or ignore", policy, flags.FAILURE_POLICY)
+	}
+}
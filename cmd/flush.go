@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/domain"
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/flags"
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/mail"
+	"github.com/spf13/cobra"
+)
+
+var flushCmd = &cobra.Command{
+	Use:   "flush",
+	Short: "Send all mails of the spool directory",
+	Long: `Send all mails, which have been spooled to the spool directory because their
+builds finished outside of the schedule. Sent mails are removed from the spool
+directory.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		smtpSettings, err := newSMTPSettingsByCommand(cmd)
+		if err != nil {
+			return fmt.Errorf("failed to initialize new config vars: %w", err)
+		}
+
+		spoolDir, err := cmd.Flags().GetString(flags.SCHEDULE_SPOOL_DIR)
+		if err != nil {
+			return fmt.Errorf("failed to detect value of %s: %w", flags.SCHEDULE_SPOOL_DIR, err)
+		}
+
+		if len(spoolDir) <= 0 {
+			return fmt.Errorf("no spool directory defined via %s", flags.SCHEDULE_SPOOL_DIR)
+		}
+
+		dryRunSettings, err := newDryRunSettingsByCommand(cmd)
+		if err != nil {
+			return fmt.Errorf("failed to initialize new dry run settings: %w", err)
+		}
+
+		plugin := mail.NewPlugin(&mail.Settings{
+			DryRun:   dryRunSettings,
+			Schedule: &domain.ScheduleSettings{SpoolDir: spoolDir},
+			SMTP:     smtpSettings,
+		})
+		n, err := plugin.Flush(cmd.Context())
+		if err != nil {
+			return applyFailurePolicy(cmd, fmt.Errorf("failed to flush spool directory after %d mails: %w", n, err))
+		}
+
+		_, err = fmt.Fprintf(os.Stdout, "%d E-Mails successfully sent", n)
+		if err != nil {
+			return fmt.Errorf("failed to write message on stdout: %w", err)
+		}
+
+		return nil
+	},
+}
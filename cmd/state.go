@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/domain"
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/flags"
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/state"
+	"github.com/spf13/cobra"
+)
+
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Manage the state store of sent notifications",
+	Long: `Manage the state store of sent notifications, which is used to suppress
+duplicate notifications of the same repository, branch, commit and status.`,
+}
+
+var stateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all sent notifications of the state store",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openStateByCommand(cmd)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "REPO\tBRANCH\tCOMMIT\tSTATUS\tSENT")
+		for _, entry := range store.Entries() {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", entry.Repo, entry.Branch, entry.Commit, entry.Status, entry.Sent.Format(time.RFC3339))
+		}
+
+		err = w.Flush()
+		if err != nil {
+			return fmt.Errorf("failed to write entries on stdout: %w", err)
+		}
+
+		return nil
+	},
+}
+
+var statePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove all notifications of the state store older than the window",
+	Long: `Remove all notifications of the state store, which have been sent before the
+window. Use --state-window=0s to remove all notifications.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		stateSettings, err := newEnabledStateSettingsByCommand(cmd)
+		if err != nil {
+			return err
+		}
+
+		var n int
+		err = state.Update(stateSettings.File, func(store *state.Store) error {
+			n = store.Prune(time.Now().Add(-stateSettings.Window))
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to prune state store: %w", err)
+		}
+
+		_, err = fmt.Fprintf(os.Stdout, "%d notifications pruned", n)
+		if err != nil {
+			return fmt.Errorf("failed to write message on stdout: %w", err)
+		}
+
+		return nil
+	},
+}
+
+func newEnabledStateSettingsByCommand(cmd *cobra.Command) (*domain.StateSettings, error) {
+	stateSettings, err := newStateSettingsByCommand(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize new state settings: %w", err)
+	}
+
+	if !stateSettings.Enabled() {
+		return nil, fmt.Errorf("no state file defined via %s", flags.STATE_FILE)
+	}
+
+	return stateSettings, nil
+}
+
+func openStateByCommand(cmd *cobra.Command) (*state.Store, error) {
+	stateSettings, err := newEnabledStateSettingsByCommand(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := state.Open(stateSettings.File)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state store: %w", err)
+	}
+
+	return store, nil
+}
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"os"
+
 	"git.cryptic.systems/volker.raschek/drone-email-docker/cmd"
 )
 
 var version string
 
 func main() {
-	_ = cmd.Execute(version)
+	err := cmd.Execute(version)
+	os.Exit(cmd.ExitCode(err))
 }
@@ -0,0 +1,120 @@
+package cicontext
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/mail"
+	"go.yaml.in/yaml/v3"
+)
+
+// Read reads the CI vars of a JSON or YAML document. The keys of the document
+// are the field names of the CI vars, for example build.number or
+// repo.fullName, matched case-insensitively. Missing fields keep their zero
+// value, nested structs are always allocated.
+func Read(r io.Reader) (*mail.CIVars, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read context: %w", err)
+	}
+
+	// YAML is decoded into generic values and encoded as JSON, because the JSON
+	// decoder matches the field names case-insensitively. JSON documents are
+	// valid YAML documents.
+	var document any
+	err = yaml.Unmarshal(b, &document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode context: %w", err)
+	}
+
+	ciVars := mail.NewCIVars()
+	if document == nil {
+		return ciVars, nil
+	}
+
+	b, err = json.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode context: %w", err)
+	}
+
+	err = json.Unmarshal(b, ciVars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode context: %w", err)
+	}
+
+	return ciVars, nil
+}
+
+// ReadFile reads the CI vars of a JSON or YAML file.
+func ReadFile(name string) (*mail.CIVars, error) {
+	// #nosec G304
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	return Read(f)
+}
+
+// Schema returns the JSON Schema of the documents read by Read. The properties
+// are derived from the fields of the CI vars, their descriptions from the usage
+// tags.
+func Schema() ([]byte, error) {
+	schema := schemaOf(reflect.TypeOf(mail.CIVars{}), make(map[reflect.Type]bool))
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "drone-email CI context"
+
+	b, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode schema: %w", err)
+	}
+
+	return append(b, '\n'), nil
+}
+
+func schemaOf(t reflect.Type, visited map[reflect.Type]bool) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": schemaOf(t.Elem(), visited)}
+	case reflect.Struct:
+		if visited[t] {
+			return map[string]any{"type": "object"}
+		}
+		visited[t] = true
+		defer delete(visited, t)
+
+		properties := make(map[string]any)
+		for _, field := range reflect.VisibleFields(t) {
+			if !field.IsExported() || field.Anonymous {
+				continue
+			}
+
+			property := schemaOf(field.Type, visited)
+			if usage, ok := field.Tag.Lookup("usage"); ok {
+				property["description"] = usage
+			}
+			properties[field.Name] = property
+		}
+
+		return map[string]any{
+			"properties": properties,
+			"type":       "object",
+		}
+	default:
+		return map[string]any{}
+	}
+}
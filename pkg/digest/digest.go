@@ -0,0 +1,249 @@
+package digest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/fileutil"
+)
+
+const fileExtension = ".json"
+
+// Entry is a build queued for the digest.
+type Entry struct {
+	Data   json.RawMessage `json:"data"`
+	Queued time.Time       `json:"queued"`
+}
+
+// Append appends the entry to the queue. If the path is a directory or ends
+// with a path separator, the entry is written as a file into the directory.
+// Otherwise the entry is appended as a line to the file. The queue is locked
+// while the entry is appended.
+func Append(path string, entry *Entry) error {
+	return fileutil.WithLock(lockName(path), func() error {
+		return appendEntry(path, entry)
+	})
+}
+
+func appendEntry(path string, entry *Entry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode entry: %w", err)
+	}
+
+	if isDir(path) {
+		name := filepath.Join(path, fmt.Sprintf("%d%s", entry.Queued.UnixNano(), fileExtension))
+		return fileutil.WriteFile(name, b)
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o750)
+	if err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(path), err)
+	}
+
+	// #nosec G304
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+
+	_, err = f.Write(append(b, '\n'))
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Read returns all entries of the queue, ordered by the time they have been
+// queued. A queue which does not exist is empty. The queue is locked while it
+// is read.
+func Read(path string) ([]*Entry, error) {
+	var entries []*Entry
+	err := fileutil.WithLock(lockName(path), func() error {
+		var err error
+		if isDir(path) {
+			entries, err = readDir(path)
+		} else {
+			entries, err = readFile(path)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Queued.Before(entries[j].Queued)
+	})
+
+	return entries, nil
+}
+
+// Remove removes the first n entries of the queue, which have been returned by
+// Read. Entries queued afterwards are kept, because the queue is locked while
+// the entries are removed.
+func Remove(path string, n int) error {
+	return fileutil.WithLock(lockName(path), func() error {
+		return removeEntries(path, n)
+	})
+}
+
+func removeEntries(path string, n int) error {
+	if isDir(path) {
+		names, err := dirFileNames(path)
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i < n && i < len(names); i++ {
+			err = os.Remove(names[i])
+			if err != nil {
+				return fmt.Errorf("failed to remove %s: %w", names[i], err)
+			}
+		}
+
+		return nil
+	}
+
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	lines := bytes.SplitAfter(b, []byte("\n"))
+	remaining := make([]byte, 0)
+	removed := 0
+	for _, line := range lines {
+		if len(bytes.TrimSpace(line)) <= 0 {
+			continue
+		}
+		if removed < n {
+			removed++
+			continue
+		}
+		remaining = append(remaining, line...)
+	}
+
+	return fileutil.WriteFile(path, remaining)
+}
+
+func readDir(dir string) ([]*Entry, error) {
+	names, err := dirFileNames(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*Entry, 0, len(names))
+	for _, name := range names {
+		// #nosec G304
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+
+		entry := new(Entry)
+		err = json.Unmarshal(b, entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", name, err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func readFile(name string) ([]*Entry, error) {
+	// #nosec G304
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return []*Entry{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	entries := make([]*Entry, 0)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for i := 1; scanner.Scan(); i++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) <= 0 {
+			continue
+		}
+
+		entry := new(Entry)
+		err = json.Unmarshal(line, entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode line %d of %s: %w", i, name, err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	return entries, nil
+}
+
+// dirFileNames returns the sorted names of all entry files of the directory.
+// The names start with the time the entry has been queued.
+func dirFileNames(dir string) ([]string, error) {
+	dirEntries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory %s: %w", dir, err)
+	}
+
+	names := make([]string, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || !strings.HasSuffix(dirEntry.Name(), fileExtension) {
+			continue
+		}
+		names = append(names, filepath.Join(dir, dirEntry.Name()))
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// lockName returns the name of the file, which is locked while the queue is
+// read or written. The lock file of a directory is placed next to it.
+func lockName(path string) string {
+	return filepath.Clean(path)
+}
+
+// isDir returns true if the path is an existing directory or ends with a path
+// separator.
+func isDir(path string) bool {
+	if strings.HasSuffix(path, string(os.PathSeparator)) || strings.HasSuffix(path, "/") {
+		return true
+	}
+
+	fileInfo, err := os.Stat(path)
+	return err == nil && fileInfo.IsDir()
+}
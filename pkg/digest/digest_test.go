@@ -0,0 +1,81 @@
+package digest
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAppendConcurrent(t *testing.T) {
+	testCases := []struct {
+		name string
+		path string
+	}{
+		{name: "file", path: "digest.jsonl"},
+		{name: "directory", path: "digest/"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), testCase.path)
+			if strings.HasSuffix(testCase.path, "/") {
+				path += "/"
+			}
+
+			now := time.Now()
+			err := Append(path, &Entry{Data: json.RawMessage(`0`), Queued: now})
+			if err != nil {
+				t.Fatalf("failed to append entry: %v", err)
+			}
+
+			entries, err := Read(path)
+			if err != nil {
+				t.Fatalf("failed to read entries: %v", err)
+			}
+
+			// Builds are queued while the read entries are removed
+			var wg sync.WaitGroup
+			errs := make(chan error, 11)
+			for i := range 10 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					errs <- Append(path, &Entry{
+						Data:   json.RawMessage(fmt.Sprintf("%d", i+1)),
+						Queued: now.Add(time.Duration(i+1) * time.Millisecond),
+					})
+				}()
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- Remove(path, len(entries))
+			}()
+			wg.Wait()
+			close(errs)
+
+			for err := range errs {
+				if err != nil {
+					t.Fatalf("failed to update queue: %v", err)
+				}
+			}
+
+			entries, err = Read(path)
+			if err != nil {
+				t.Fatalf("failed to read entries: %v", err)
+			}
+			if len(entries) != 10 {
+				t.Fatalf("expected 10 queued entries, got %d", len(entries))
+			}
+			for _, entry := range entries {
+				if string(entry.Data) == "0" {
+					t.Errorf("expected removed entry not to be queued anymore")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package directory
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// GroupPrefix is the prefix which marks a recipient as group alias, for
+// example @backend.
+const GroupPrefix = "@"
+
+var ErrUnknownGroup = errors.New("unknown group")
+
+// Member is a person of the team directory. Email is the canonical address
+// mails are sent to. Usernames and Emails are alternative identities, for
+// example SCM usernames or personal and noreply addresses, which are mapped to
+// the canonical address.
+type Member struct {
+	Email     string   `yaml:"email"`
+	Emails    []string `yaml:"emails"`
+	Groups    []string `yaml:"groups"`
+	Name      string   `yaml:"name"`
+	Usernames []string `yaml:"usernames"`
+}
+
+type Directory struct {
+	emails    map[string]*Member
+	groups    map[string][]*Member
+	usernames map[string]*Member
+}
+
+// LookupEmail returns the member owning the canonical or alternative address.
+func (d *Directory) LookupEmail(email string) (*Member, bool) {
+	member, ok := d.emails[normalize(email)]
+	return member, ok
+}
+
+// LookupGroup returns the members of the group. The group name can be passed
+// with or without GroupPrefix.
+func (d *Directory) LookupGroup(group string) ([]*Member, bool) {
+	members, ok := d.groups[normalize(strings.TrimPrefix(group, GroupPrefix))]
+	return members, ok
+}
+
+// LookupUsername returns the member of the username.
+func (d *Directory) LookupUsername(username string) (*Member, bool) {
+	member, ok := d.usernames[normalize(username)]
+	return member, ok
+}
+
+func (d *Directory) add(member *Member) error {
+	member.Email = strings.TrimSpace(member.Email)
+	if len(member.Email) <= 0 {
+		return fmt.Errorf("member %s has no email", member.Name)
+	}
+
+	for _, email := range append([]string{member.Email}, member.Emails...) {
+		if len(strings.TrimSpace(email)) <= 0 {
+			continue
+		}
+		if other, ok := d.emails[normalize(email)]; ok && other != member {
+			return fmt.Errorf("email %s is assigned to %s and %s", email, other.Email, member.Email)
+		}
+		d.emails[normalize(email)] = member
+	}
+
+	for _, username := range member.Usernames {
+		if len(strings.TrimSpace(username)) <= 0 {
+			continue
+		}
+		if other, ok := d.usernames[normalize(username)]; ok && other != member {
+			return fmt.Errorf("username %s is assigned to %s and %s", username, other.Email, member.Email)
+		}
+		d.usernames[normalize(username)] = member
+	}
+
+	for _, group := range member.Groups {
+		group = normalize(strings.TrimPrefix(group, GroupPrefix))
+		if len(group) <= 0 {
+			continue
+		}
+		d.groups[group] = append(d.groups[group], member)
+	}
+
+	return nil
+}
+
+type file struct {
+	Members []*Member `yaml:"members"`
+}
+
+// ReadFile reads a directory from a YAML, JSON or CSV file. The format is
+// detected by the file extension.
+func ReadFile(name string) (*Directory, error) {
+	// #nosec G304
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var members []*Member
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		members, err = readCSV(f)
+	default:
+		members, err = readYAML(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	return New(members)
+}
+
+// New returns a directory of the members.
+func New(members []*Member) (*Directory, error) {
+	d := &Directory{
+		emails:    make(map[string]*Member),
+		groups:    make(map[string][]*Member),
+		usernames: make(map[string]*Member),
+	}
+
+	for _, member := range members {
+		err := d.add(member)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return d, nil
+}
+
+// readCSV reads members from CSV. The first line is the header, which names
+// the columns email, name, emails, usernames and groups. Multiple values of a
+// column are separated by semicolons.
+func readCSV(r io.Reader) ([]*Member, error) {
+	csvReader := csv.NewReader(r)
+	csvReader.Comment = '#'
+	csvReader.TrimLeadingSpace = true
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, column := range header {
+		columns[normalize(column)] = i
+	}
+
+	if _, ok := columns["email"]; !ok {
+		return nil, fmt.Errorf("missing column email")
+	}
+
+	value := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	values := func(record []string, column string) []string {
+		result := make([]string, 0)
+		for _, v := range strings.Split(value(record, column), ";") {
+			if v = strings.TrimSpace(v); len(v) > 0 {
+				result = append(result, v)
+			}
+		}
+		return result
+	}
+
+	members := make([]*Member, 0)
+	for {
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read record: %w", err)
+		}
+
+		members = append(members, &Member{
+			Email:     value(record, "email"),
+			Emails:    values(record, "emails"),
+			Groups:    values(record, "groups"),
+			Name:      value(record, "name"),
+			Usernames: values(record, "usernames"),
+		})
+	}
+
+	return members, nil
+}
+
+func readYAML(r io.Reader) ([]*Member, error) {
+	f := new(file)
+	err := yaml.NewDecoder(r).Decode(f)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to decode yaml: %w", err)
+	}
+
+	return f.Members, nil
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
@@ -1,7 +1,8 @@
 package domain
 
 type Author struct {
-	Avatar string
-	Email  string
-	Name   string
+	Avatar   string `env:"DRONE_COMMIT_AUTHOR_AVATAR" usage:"Avatar of the commit author"`
+	Email    string `env:"DRONE_COMMIT_AUTHOR_EMAIL" usage:"E-Mail of the commit author"`
+	Name     string `env:"DRONE_COMMIT_AUTHOR_NAME" usage:"Name of the commit author"`
+	Username string `env:"DRONE_COMMIT_AUTHOR" usage:"Username of the commit author"`
 }
@@ -2,14 +2,22 @@ package domain
 
 import "time"
 
+const (
+	StatusError   = "error"
+	StatusFailure = "failure"
+	StatusKilled  = "killed"
+	StatusSuccess = "success"
+)
+
 type Build struct {
-	Created  int64
-	Event    string
-	Finished int64
-	Link     string
-	Number   int
-	Started  int64
-	Status   string
+	Created  int64  `env:"DRONE_BUILD_CREATED" usage:"Unix timestamp when the build has been created"`
+	Cron     string `env:"DRONE_CRON" usage:"Name of the cron job, which triggered the build"`
+	Event    string `env:"DRONE_BUILD_EVENT" default:"push" usage:"Build event"`
+	Finished int64  `env:"DRONE_BUILD_FINISHED" usage:"Unix timestamp when the build has been finished"`
+	Link     string `env:"DRONE_BUILD_LINK" usage:"Build link"`
+	Number   int    `env:"DRONE_BUILD_NUMBER" usage:"Build number"`
+	Started  int64  `env:"DRONE_BUILD_STARTED" usage:"Unix timestamp when the build has been started"`
+	Status   string `env:"DRONE_BUILD_STATUS" default:"success" usage:"Build status"`
 }
 
 func (b *Build) CreatedToTimeFormat(format string) string {
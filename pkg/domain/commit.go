@@ -1,10 +1,18 @@
 package domain
 
 type Commit struct {
+	After   string `env:"DRONE_COMMIT_AFTER" usage:"SHA sum of the commit after the push"`
 	Author  *Author
-	Branch  string
-	Link    string
-	Message string
-	Ref     string
-	Sha     string
+	Before  string `env:"DRONE_COMMIT_BEFORE" usage:"SHA sum of the commit before the push"`
+	Branch  string `env:"DRONE_COMMIT_BRANCH" default:"master" usage:"Commit branch"`
+	Link    string `env:"DRONE_COMMIT_LINK" usage:"Link to the commit"`
+	Message string `env:"DRONE_COMMIT_MESSAGE" usage:"Commit message"`
+	Ref     string `env:"DRONE_COMMIT_REF" default:"refs/heads/master" usage:"Commit reference"`
+	Sha     string `env:"DRONE_COMMIT_SHA" usage:"SHA sum of the commit"`
+}
+
+// ParsedMessage returns the commit message split into subject, body and
+// trailers.
+func (c *Commit) ParsedMessage() *CommitMessage {
+	return ParseCommitMessage(c.Message)
 }
@@ -0,0 +1,144 @@
+package domain
+
+import (
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+const (
+	TrailerCoAuthoredBy = "Co-authored-by"
+	TrailerReviewedBy   = "Reviewed-by"
+	TrailerSignedOffBy  = "Signed-off-by"
+)
+
+var trailerRegexp = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9-]*):\s*(.*)$`)
+
+// CommitMessage is a commit message split into its subject, body and the
+// trailers of the last paragraph.
+type CommitMessage struct {
+	Subject  string
+	Body     string
+	Trailers []*Trailer
+}
+
+// CoAuthors returns all authors of Co-authored-by trailers.
+func (c *CommitMessage) CoAuthors() []*Author {
+	return c.TrailerAuthors(TrailerCoAuthoredBy)
+}
+
+// TrailerAuthors returns the authors of all trailers with the given key. The
+// key is compared case-insensitive. Values which are not valid RFC 5322
+// addresses are skipped.
+func (c *CommitMessage) TrailerAuthors(key string) []*Author {
+	authors := make([]*Author, 0)
+	for _, value := range c.TrailerValues(key) {
+		address, err := mail.ParseAddress(value)
+		if err != nil {
+			continue
+		}
+
+		authors = append(authors, &Author{
+			Email: address.Address,
+			Name:  address.Name,
+		})
+	}
+
+	return authors
+}
+
+// TrailerValues returns the values of all trailers with the given key. The key
+// is compared case-insensitive.
+func (c *CommitMessage) TrailerValues(key string) []string {
+	values := make([]string, 0)
+	for _, trailer := range c.Trailers {
+		if strings.EqualFold(trailer.Key, key) {
+			values = append(values, trailer.Value)
+		}
+	}
+
+	return values
+}
+
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+// ParseCommitMessage splits a commit message into subject, body and trailers.
+// The subject is the first paragraph, the trailers are taken from the last
+// paragraph if each of its lines is a trailer or a continuation of one.
+func ParseCommitMessage(message string) *CommitMessage {
+	paragraphs := splitParagraphs(message)
+	if len(paragraphs) <= 0 {
+		return &CommitMessage{
+			Trailers: make([]*Trailer, 0),
+		}
+	}
+
+	commitMessage := &CommitMessage{
+		Subject:  strings.Join(strings.Fields(strings.Join(paragraphs[0], " ")), " "),
+		Trailers: make([]*Trailer, 0),
+	}
+
+	bodyParagraphs := paragraphs[1:]
+	if len(bodyParagraphs) > 0 {
+		trailers, ok := parseTrailers(bodyParagraphs[len(bodyParagraphs)-1])
+		if ok {
+			commitMessage.Trailers = trailers
+			bodyParagraphs = bodyParagraphs[:len(bodyParagraphs)-1]
+		}
+	}
+
+	body := make([]string, 0, len(bodyParagraphs))
+	for _, paragraph := range bodyParagraphs {
+		body = append(body, strings.Join(paragraph, "\n"))
+	}
+	commitMessage.Body = strings.Join(body, "\n\n")
+
+	return commitMessage
+}
+
+func parseTrailers(lines []string) ([]*Trailer, bool) {
+	trailers := make([]*Trailer, 0, len(lines))
+	for _, line := range lines {
+		if len(trailers) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			trailers[len(trailers)-1].Value += " " + strings.TrimSpace(line)
+			continue
+		}
+
+		matches := trailerRegexp.FindStringSubmatch(line)
+		if matches == nil {
+			return nil, false
+		}
+
+		trailers = append(trailers, &Trailer{
+			Key:   matches[1],
+			Value: strings.TrimSpace(matches[2]),
+		})
+	}
+
+	return trailers, true
+}
+
+func splitParagraphs(message string) [][]string {
+	paragraphs := make([][]string, 0)
+	paragraph := make([]string, 0)
+	for _, line := range strings.Split(strings.ReplaceAll(message, "\r\n", "\n"), "\n") {
+		line = strings.TrimRight(line, " \t")
+		if len(line) <= 0 {
+			if len(paragraph) > 0 {
+				paragraphs = append(paragraphs, paragraph)
+				paragraph = make([]string, 0)
+			}
+			continue
+		}
+		paragraph = append(paragraph, line)
+	}
+
+	if len(paragraph) > 0 {
+		paragraphs = append(paragraphs, paragraph)
+	}
+
+	return paragraphs
+}
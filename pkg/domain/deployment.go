@@ -0,0 +1,23 @@
+package domain
+
+const (
+	EventPromote  = "promote"
+	EventRollback = "rollback"
+)
+
+type DeploymentSettings struct {
+	// HistoryFile is the path to the JSON file, which records the built commits
+	// and the last deployment to each environment. If empty, the changes since
+	// the last deployment are not listed.
+	HistoryFile string
+
+	// Recipients are the recipients of deployments to environments.
+	Recipients []*DeploymentRecipients
+}
+
+// DeploymentRecipients receive the mails of deployments to all environments
+// matching the glob pattern Environment.
+type DeploymentRecipients struct {
+	Environment string   `json:"environment"`
+	Recipients  []string `json:"recipients"`
+}
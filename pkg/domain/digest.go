@@ -0,0 +1,21 @@
+package domain
+
+const (
+	NotifyModeDigest  = "digest"
+	NotifyModeInstant = "instant"
+)
+
+type DigestSettings struct {
+	// Mode defines whether mails are sent instantly or builds are queued for a
+	// digest. Supported are instant and digest.
+	Mode string
+
+	// Spool is the path to a file or directory the builds are queued to.
+	Spool string
+}
+
+// Enabled returns true if builds are queued for a digest instead of sending
+// mails.
+func (s *DigestSettings) Enabled() bool {
+	return s.Mode == NotifyModeDigest
+}
@@ -0,0 +1,20 @@
+package domain
+
+type DroneAPISettings struct {
+	// LogLines is the number of the last log lines of each failed step.
+	LogLines int
+
+	// Server is the URL of the drone server. If empty, the server of the build
+	// is used.
+	Server string
+
+	// Token is the personal token of a drone user, which has read access to the
+	// repository. If empty, the drone API is not requested.
+	Token string
+}
+
+// Enabled returns true if the details of the build are fetched from the drone
+// API.
+func (s *DroneAPISettings) Enabled() bool {
+	return len(s.Token) > 0
+}
@@ -0,0 +1,12 @@
+package domain
+
+type DryRunSettings struct {
+	// Enabled prints the mails instead of sending them. No state, history or
+	// spool is written.
+	Enabled bool
+
+	// SMTP verifies the SMTP session in a dry run. The client connects,
+	// authenticates and sends the MAIL and RCPT commands, which are reset
+	// afterwards.
+	SMTP bool
+}
@@ -0,0 +1,23 @@
+package domain
+
+type EscalationSettings struct {
+	// HistoryFile is the path to the JSON file, which counts the consecutive
+	// failed builds of each branch. If empty, failures are not escalated.
+	HistoryFile string
+
+	// Levels are the escalation levels. All levels whose threshold has been
+	// reached add their recipients.
+	Levels []*EscalationLevel
+}
+
+// Enabled returns true if repeated failures are escalated.
+func (s *EscalationSettings) Enabled() bool {
+	return len(s.HistoryFile) > 0
+}
+
+// EscalationLevel adds recipients once a branch failed Threshold times in a
+// row.
+type EscalationLevel struct {
+	Recipients []string `json:"recipients"`
+	Threshold  int      `json:"threshold"`
+}
@@ -0,0 +1,9 @@
+package domain
+
+// Failure policies define how errors of the delivery of mails are handled.
+// Configuration and rendering errors always fail.
+const (
+	FailurePolicyFail   = "fail"
+	FailurePolicyIgnore = "ignore"
+	FailurePolicyWarn   = "warn"
+)
@@ -0,0 +1,45 @@
+package domain
+
+const (
+	ConditionAlways          = "always"
+	ConditionChange          = "change"
+	ConditionFailure         = "failure"
+	ConditionFailureAndFixed = "failure-and-fixed"
+	ConditionFixed           = "fixed"
+)
+
+// Filter is a list of patterns of which at least one of Include must and none
+// of Exclude must match. An empty Include list matches all values.
+type Filter struct {
+	Exclude []string
+	Include []string
+}
+
+type FilterSettings struct {
+	// Branches is a filter of glob patterns, e.g. release/*, for the commit
+	// branch.
+	Branches *Filter
+
+	// Condition defines based on the transition of the build status when mails
+	// are sent. Supported are always, change, failure, fixed and
+	// failure-and-fixed.
+	Condition string
+
+	// DeployTo is a filter of deployment targets.
+	DeployTo *Filter
+
+	// Events is a filter of build events, e.g. push, tag or cron.
+	Events *Filter
+
+	// Repos is a filter of glob patterns for the full repository name.
+	Repos *Filter
+
+	// Statuses is a filter of build statuses.
+	Statuses *Filter
+
+	// Tags is a filter of regular expressions for the tag.
+	Tags *Filter
+
+	// When is an expression, which must evaluate to true to send mails.
+	When string
+}
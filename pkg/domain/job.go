@@ -1,8 +1,9 @@
 package domain
 
 type Job struct {
-	ExitCode int
-	Finished int64
-	Started  int64
-	Status   string
+	ExitCode int    `env:"DRONE_JOB_EXIT_CODE" usage:"Job exit code"`
+	Finished int64  `env:"DRONE_JOB_FINISHED" usage:"Unix timestamp when the job has been finished"`
+	Number   int    `env:"DRONE_JOB_NUMBER" usage:"Job number"`
+	Started  int64  `env:"DRONE_JOB_STARTED" usage:"Unix timestamp when the job has been started"`
+	Status   string `env:"DRONE_JOB_STATUS" usage:"Job status"`
 }
@@ -1,6 +1,6 @@
 package domain
 
 type PrevBuild struct {
-	Number int
-	Status string
+	Number int    `env:"DRONE_PREV_BUILD_NUMBER" usage:"Previous build number"`
+	Status string `env:"DRONE_PREV_BUILD_STATUS" usage:"Previous build status"`
 }
@@ -1,5 +1,5 @@
 package domain
 
 type PrevCommit struct {
-	Sha string
+	Sha string `env:"DRONE_PREV_COMMIT_SHA" usage:"Previous commit sha sum"`
 }
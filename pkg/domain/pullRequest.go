@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	SCMBitbucket = "bitbucket"
+	SCMForgejo   = "forgejo"
+	SCMGitea     = "gitea"
+	SCMGitHub    = "github"
+	SCMGitLab    = "gitlab"
+	SCMGogs      = "gogs"
+)
+
+// PullRequest is the pull request of a build. GitLab calls them merge
+// requests.
+type PullRequest struct {
+	// Author is the user, who opened the pull request. It is nil, if the CI
+	// system does not provide the author.
+	Author *PullRequestAuthor
+
+	// Link is the URL of the pull request. If the CI system does not provide the
+	// link, it is derived from the link of the repository.
+	Link string
+
+	Number       int
+	SourceBranch string
+	TargetBranch string
+
+	// Title is the title of the pull request. If the CI system does not provide
+	// the title, it is the subject of the commit or merge message.
+	Title string
+}
+
+// PullRequestAuthor is the user, who opened the pull request. The author of
+// the pull request differs from the author of the commit, if the pull request
+// contains commits of others. Drone does not provide the author, therefore its
+// fields have no environment variables, but only a usage for the JSON Schema of
+// the context file.
+type PullRequestAuthor struct {
+	Avatar   string `usage:"Avatar of the pull request author"`
+	Email    string `usage:"E-Mail of the pull request author"`
+	Name     string `usage:"Name of the pull request author"`
+	Username string `usage:"Username of the pull request author"`
+}
+
+// Author returns the pull request author as author, which can be resolved to
+// a recipient like the author of a commit.
+func (a *PullRequestAuthor) Author() *Author {
+	return &Author{
+		Avatar:   a.Avatar,
+		Email:    a.Email,
+		Name:     a.Name,
+		Username: a.Username,
+	}
+}
+
+// IsDefined returns true, if the email or username of the author is known.
+func (a *PullRequestAuthor) IsDefined() bool {
+	return a != nil && (len(a.Email) > 0 || len(a.Username) > 0)
+}
+
+// PullRequestLink returns the URL of the pull request of the repository. The
+// path depends on the SCM provider, e.g. /pull/1 for GitHub or
+// /-/merge_requests/1 for GitLab. If the SCM provider is unknown, for example
+// plain git, it is detected by the host of the repository link. If the host
+// does not belong to a known provider, like a self-hosted instance, an empty
+// link is returned, because the path can not be derived.
+func PullRequestLink(scm string, repoLink string, number int) string {
+	if len(repoLink) <= 0 || number <= 0 {
+		return ""
+	}
+	repoLink = strings.TrimSuffix(repoLink, "/")
+
+	switch scm {
+	case SCMBitbucket, SCMForgejo, SCMGitea, SCMGitHub, SCMGitLab, SCMGogs:
+	default:
+		scm = detectSCM(repoLink)
+	}
+
+	switch scm {
+	case SCMBitbucket:
+		return fmt.Sprintf("%s/pull-requests/%d", repoLink, number)
+	case SCMForgejo, SCMGitea, SCMGogs:
+		return fmt.Sprintf("%s/pulls/%d", repoLink, number)
+	case SCMGitHub:
+		return fmt.Sprintf("%s/pull/%d", repoLink, number)
+	case SCMGitLab:
+		return fmt.Sprintf("%s/-/merge_requests/%d", repoLink, number)
+	default:
+		return ""
+	}
+}
+
+// detectSCM returns the SCM provider by the host of the repository link, for
+// example github.com or gitlab.example.com. If the host does not contain the
+// name of a provider, an empty string is returned.
+func detectSCM(repoLink string) string {
+	u, err := url.Parse(repoLink)
+	if err != nil {
+		return ""
+	}
+
+	host := strings.ToLower(u.Hostname())
+	switch {
+	case strings.Contains(host, SCMBitbucket):
+		return SCMBitbucket
+	case strings.Contains(host, SCMForgejo), strings.Contains(host, "codeberg"):
+		return SCMForgejo
+	case strings.Contains(host, SCMGitea):
+		return SCMGitea
+	case strings.Contains(host, SCMGitHub):
+		return SCMGitHub
+	case strings.Contains(host, SCMGitLab):
+		return SCMGitLab
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,30 @@
+package domain
+
+import "testing"
+
+func TestPullRequestLink(t *testing.T) {
+	testCases := []struct {
+		name     string
+		scm      string
+		repoLink string
+		expected string
+	}{
+		{name: "github", scm: SCMGitHub, repoLink: "https://git.example.com/max/app/", expected: "https://git.example.com/max/app/pull/12"},
+		{name: "gitea", scm: SCMGitea, repoLink: "https://git.example.com/max/app", expected: "https://git.example.com/max/app/pulls/12"},
+		{name: "github by host", scm: "git", repoLink: "https://github.com/max/app", expected: "https://github.com/max/app/pull/12"},
+		{name: "gitlab by host", scm: "git", repoLink: "https://gitlab.example.com/max/app", expected: "https://gitlab.example.com/max/app/-/merge_requests/12"},
+		{name: "bitbucket by host", scm: "", repoLink: "https://bitbucket.org/max/app", expected: "https://bitbucket.org/max/app/pull-requests/12"},
+		{name: "forgejo by host", scm: "git", repoLink: "https://codeberg.org/max/app", expected: "https://codeberg.org/max/app/pulls/12"},
+		{name: "unknown host", scm: "git", repoLink: "https://git.example.com/max/app", expected: ""},
+		{name: "no repository link", scm: SCMGitHub, expected: ""},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := PullRequestLink(testCase.scm, testCase.repoLink, 12)
+			if actual != testCase.expected {
+				t.Errorf("expected %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package domain
+
+type RecipientSettings struct {
+	// AllowList is a list of addresses and domains mails may be sent to. If
+	// empty, all addresses are allowed.
+	AllowList []string
+
+	// AllowListPrivateOnly applies the AllowList only for private repositories.
+	AllowListPrivateOnly bool
+
+	// CoAuthors adds the authors of Co-authored-by trailers to the recipients.
+	CoAuthors bool
+
+	// Conditional is a list of recipients, which receive mails only when their
+	// expression evaluates to true.
+	Conditional []*ConditionalRecipient
+
+	// DirectoryFile is the path to a YAML, JSON or CSV file of the team directory,
+	// which maps usernames, alternative addresses and group aliases to canonical
+	// addresses.
+	DirectoryFile string
+
+	// DenyList is a list of addresses and domains mails must not be sent to.
+	DenyList []string
+
+	// FallbackAddress receives the mails of dropped recipients, instead of
+	// discarding them.
+	FallbackAddress string
+
+	// OptOutFile is the path to a YAML or JSON file of recipients which opted out
+	// of mails in general or for specific build statuses.
+	OptOutFile string
+
+	// PullRequestAuthor sends the mails of pull request builds to the author of
+	// the pull request instead of the author of the commit.
+	PullRequestAuthor bool
+
+	// Trailers is a list of additional trailer keys, for example Reviewed-by or
+	// Signed-off-by, whose addresses are added to the recipients.
+	Trailers []string
+}
+
+// ConditionalRecipient is a recipient, which receives mails only when the
+// expression When evaluates to true.
+type ConditionalRecipient struct {
+	Address string `json:"address"`
+	When    string `json:"when"`
+}
@@ -1,5 +1,5 @@
 package domain
 
 type Remote struct {
-	URL string
+	URL string `env:"DRONE_REMOTE_URL" usage:"Clone URL of the repository"`
 }
@@ -1,13 +1,13 @@
 package domain
 
 type Repo struct {
-	Avatar   string
-	Branch   string
-	FullName string
-	Link     string
-	Name     string
-	Owner    string
-	Private  bool
-	SCM      string
-	Trusted  bool
+	Avatar   string `env:"DRONE_REPO_AVATAR" usage:"Avatar URL of the repository"`
+	Branch   string `env:"DRONE_REPO_BRANCH" default:"master" usage:"Branch of the repository"`
+	FullName string `env:"DRONE_REPO" usage:"Full name of the repository"`
+	Link     string `env:"DRONE_REPO_LINK" usage:"URL to the repository"`
+	Name     string `env:"DRONE_REPO_NAME" usage:"Name of the repository"`
+	Owner    string `env:"DRONE_REPO_OWNER" usage:"Name of the repository owner"`
+	Private  bool   `env:"DRONE_REPO_PRIVATE" default:"true" usage:"Repository is private"`
+	SCM      string `env:"DRONE_REPO_SCM" default:"git" usage:"Source code management provider"`
+	Trusted  bool   `env:"DRONE_REPO_TRUSTED" usage:"Repository is trusted"`
 }
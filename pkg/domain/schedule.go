@@ -0,0 +1,40 @@
+package domain
+
+const (
+	ScheduleActionOnCall = "on-call"
+	ScheduleActionSkip   = "skip"
+	ScheduleActionSpool  = "spool"
+)
+
+type ScheduleSettings struct {
+	// Action defines what happens with mails of builds finished outside of the
+	// schedule. Supported are skip, on-call and spool.
+	Action string
+
+	// HolidayFile is the path to a file of holidays in the format 2006-01-02, one
+	// per line.
+	HolidayFile string
+
+	// OnCallAddress receives the mails outside of the schedule, when the action is
+	// on-call.
+	OnCallAddress string
+
+	// SpoolDir is the directory mails are written to outside of the schedule,
+	// when the action is spool.
+	SpoolDir string
+
+	// Timezone of the windows, weekdays and holidays.
+	Timezone string
+
+	// Weekdays on which mails are sent, for example mon or fri.
+	Weekdays []string
+
+	// Windows are the daily time windows in which mails are sent, for example
+	// 08:00-18:00.
+	Windows []string
+}
+
+// Enabled returns true if the mails are restricted by a schedule.
+func (s *ScheduleSettings) Enabled() bool {
+	return len(s.Windows) > 0 || len(s.Weekdays) > 0 || len(s.HolidayFile) > 0
+}
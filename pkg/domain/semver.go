@@ -0,0 +1,14 @@
+package domain
+
+// Semver is the semantic version of a tag. If the tag is not a valid semantic
+// version, Error contains the reason.
+type Semver struct {
+	Build      string `env:"DRONE_SEMVER_BUILD" usage:"Build metadata of the semantic version"`
+	Error      string `env:"DRONE_SEMVER_ERROR" usage:"Error, if the tag is not a semantic version"`
+	Major      int    `env:"DRONE_SEMVER_MAJOR" usage:"Major version of the semantic version"`
+	Minor      int    `env:"DRONE_SEMVER_MINOR" usage:"Minor version of the semantic version"`
+	Patch      int    `env:"DRONE_SEMVER_PATCH" usage:"Patch version of the semantic version"`
+	Prerelease string `env:"DRONE_SEMVER_PRERELEASE" usage:"Pre-release of the semantic version"`
+	Short      string `env:"DRONE_SEMVER_SHORT" usage:"Semantic version without pre-release and build metadata"`
+	Version    string `env:"DRONE_SEMVER" usage:"Semantic version of the tag"`
+}
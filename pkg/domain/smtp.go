@@ -5,6 +5,7 @@ type SMTPSettings struct {
 	FromName              string
 	HELOName              string
 	Host                  string
+	ListUnsubscribe       string
 	Password              string
 	Port                  int
 	StartTLS              bool
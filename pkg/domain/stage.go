@@ -0,0 +1,26 @@
+package domain
+
+import "time"
+
+type Stage struct {
+	Arch      string   `env:"DRONE_STAGE_ARCH" usage:"Architecture of the stage"`
+	DependsOn []string `env:"DRONE_STAGE_DEPENDS_ON" usage:"Names of the stages the stage depends on"`
+	Finished  int64    `env:"DRONE_STAGE_FINISHED" usage:"Unix timestamp when the stage has been finished"`
+	Kind      string   `env:"DRONE_STAGE_KIND" usage:"Kind of the stage"`
+	Machine   string   `env:"DRONE_STAGE_MACHINE" usage:"Name of the runner machine"`
+	Name      string   `env:"DRONE_STAGE_NAME" usage:"Name of the stage"`
+	Number    int      `env:"DRONE_STAGE_NUMBER" usage:"Number of the stage"`
+	OS        string   `env:"DRONE_STAGE_OS" usage:"Operating system of the stage"`
+	Started   int64    `env:"DRONE_STAGE_STARTED" usage:"Unix timestamp when the stage has been started"`
+	Status    string   `env:"DRONE_STAGE_STATUS" usage:"Status of the stage"`
+	Type      string   `env:"DRONE_STAGE_TYPE" usage:"Type of the stage"`
+	Variant   string   `env:"DRONE_STAGE_VARIANT" usage:"Architecture variant of the stage"`
+}
+
+func (s *Stage) FinishedToTimeFormat(format string) string {
+	return time.Unix(s.Finished, 0).Format(format)
+}
+
+func (s *Stage) StartedToTimeFormat(format string) string {
+	return time.Unix(s.Started, 0).Format(format)
+}
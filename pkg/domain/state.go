@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+type StateSettings struct {
+	// File is the path to the JSON file of sent notifications. If empty, no
+	// duplicate notifications are suppressed.
+	File string
+
+	// Window is the duration in which an equivalent notification is not sent
+	// again.
+	Window time.Duration
+}
+
+// Enabled returns true if duplicate notifications are suppressed.
+func (s *StateSettings) Enabled() bool {
+	return len(s.File) > 0
+}
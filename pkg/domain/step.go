@@ -0,0 +1,6 @@
+package domain
+
+type Step struct {
+	Name   string `env:"DRONE_STEP_NAME" usage:"Name of the step"`
+	Number int    `env:"DRONE_STEP_NUMBER" usage:"Number of the step"`
+}
@@ -0,0 +1,24 @@
+package domain
+
+import "fmt"
+
+type System struct {
+	Host     string `env:"DRONE_SYSTEM_HOST" usage:"Host name of the drone server"`
+	Hostname string `env:"DRONE_SYSTEM_HOSTNAME" usage:"Host name of the drone server"`
+	Proto    string `env:"DRONE_SYSTEM_PROTO" usage:"Protocol of the drone server"`
+	Version  string `env:"DRONE_SYSTEM_VERSION" usage:"Version of the drone server"`
+}
+
+// Link returns the URL of the CI server, for example https://drone.example.com.
+func (s *System) Link() string {
+	if len(s.Host) <= 0 {
+		return ""
+	}
+
+	proto := s.Proto
+	if len(proto) <= 0 {
+		proto = "https"
+	}
+
+	return fmt.Sprintf("%s://%s", proto, s.Host)
+}
@@ -0,0 +1,12 @@
+package domain
+
+type TemplateSettings struct {
+	// DataFiles are the paths to JSON or YAML files, whose documents are passed
+	// to the templates as .Data. The top-level keys of later files override the
+	// keys of earlier files.
+	DataFiles []string
+
+	// Vars are custom variables, which are passed to the templates as .Vars.
+	// Values can be nested maps.
+	Vars map[string]any
+}
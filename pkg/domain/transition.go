@@ -0,0 +1,38 @@
+package domain
+
+// Transition describes the change of the build status compared to the status
+// of the previous build.
+type Transition string
+
+const (
+	TransitionBroken       Transition = "broken"
+	TransitionFixed        Transition = "fixed"
+	TransitionStillFailing Transition = "still-failing"
+	TransitionStillPassing Transition = "still-passing"
+)
+
+// IsFailure returns true if the build status of the transition is not a
+// success.
+func (t Transition) IsFailure() bool {
+	return t == TransitionBroken || t == TransitionStillFailing
+}
+
+// NewTransition returns the transition from the previous to the current build
+// status. All statuses except success, for example failure, error or killed,
+// are considered as failed. An unknown previous status is considered as
+// success.
+func NewTransition(prevStatus string, status string) Transition {
+	prevFailed := len(prevStatus) > 0 && prevStatus != StatusSuccess
+	failed := status != StatusSuccess
+
+	switch {
+	case failed && prevFailed:
+		return TransitionStillFailing
+	case failed:
+		return TransitionBroken
+	case prevFailed:
+		return TransitionFixed
+	default:
+		return TransitionStillPassing
+	}
+}
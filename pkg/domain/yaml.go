@@ -1,6 +1,6 @@
 package domain
 
 type Yaml struct {
-	Signed   bool
-	Verified bool
+	Signed   bool `env:"DRONE_YAML_SIGNED" usage:"YAML is signed"`
+	Verified bool `env:"DRONE_YAML_VERIFIED" usage:"YAML is verified"`
 }
@@ -0,0 +1,131 @@
+package droneapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Build is a build of a repository with its stages.
+type Build struct {
+	Number int      `json:"number"`
+	Stages []*Stage `json:"stages"`
+	Status string   `json:"status"`
+}
+
+// Stage is a stage of a build, which consists of steps.
+type Stage struct {
+	Name   string  `json:"name"`
+	Number int     `json:"number"`
+	Status string  `json:"status"`
+	Steps  []*Step `json:"steps"`
+}
+
+// Step is a step of a stage.
+type Step struct {
+	ExitCode int    `json:"exit_code"`
+	Name     string `json:"name"`
+	Number   int    `json:"number"`
+	Status   string `json:"status"`
+}
+
+// Line is a line of the log of a step.
+type Line struct {
+	Out  string `json:"out"`
+	Pos  int    `json:"pos"`
+	Time int64  `json:"time"`
+}
+
+// Client is a client of the REST API of a drone server.
+type Client struct {
+	httpClient *http.Client
+	server     string
+	token      string
+}
+
+// Build returns the build of the repository including its stages and steps.
+func (c *Client) Build(ctx context.Context, owner string, name string, number int) (*Build, error) {
+	build := new(Build)
+	err := c.get(ctx, fmt.Sprintf("/api/repos/%s/%s/builds/%d", url.PathEscape(owner), url.PathEscape(name), number), build)
+	if err != nil {
+		return nil, err
+	}
+
+	return build, nil
+}
+
+// Logs returns the log lines of the step of the stage of the build.
+func (c *Client) Logs(ctx context.Context, owner string, name string, build int, stage int, step int) ([]*Line, error) {
+	lines := make([]*Line, 0)
+	err := c.get(ctx, fmt.Sprintf("/api/repos/%s/%s/builds/%d/logs/%d/%d", url.PathEscape(owner), url.PathEscape(name), build, stage, step), &lines)
+	if err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.server+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to request %s: %w", path, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to request %s: unexpected status code %d", path, resp.StatusCode)
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response of %s: %w", path, err)
+	}
+
+	err = json.Unmarshal(b, v)
+	if err != nil {
+		return fmt.Errorf("failed to decode response of %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// New returns a client of the drone server, e.g. https://drone.example.com,
+// which authenticates by the token. If httpClient is nil, the default client is
+// used.
+func New(server string, token string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		httpClient: httpClient,
+		server:     strings.TrimSuffix(server, "/"),
+		token:      token,
+	}
+}
+
+// Tail returns the last n lines of the log. Lines of the log containing
+// multiple lines are split.
+func Tail(lines []*Line, n int) []string {
+	tail := make([]string, 0)
+	for _, line := range lines {
+		tail = append(tail, strings.Split(strings.TrimRight(line.Out, "\r\n"), "\n")...)
+	}
+
+	if n >= 0 && len(tail) > n {
+		tail = tail[len(tail)-n:]
+	}
+
+	return tail
+}
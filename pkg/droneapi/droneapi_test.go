@@ -0,0 +1,145 @@
+package droneapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func newTestServer(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/repos/{owner}/{name}/builds/{number}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("owner") != "volker.raschek" || r.PathValue("name") != "drone-email" || r.PathValue("number") != "42" {
+			http.NotFound(w, r)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(&Build{
+			Number: 42,
+			Status: "failure",
+			Stages: []*Stage{
+				{Name: "default", Number: 1, Status: "failure", Steps: []*Step{
+					{ExitCode: 1, Name: "test", Number: 2, Status: "failure"},
+				}},
+			},
+		})
+	})
+	mux.HandleFunc("GET /api/repos/{owner}/{name}/builds/{number}/logs/{stage}/{step}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("number") != "42" || r.PathValue("stage") != "1" || r.PathValue("step") != "2" {
+			http.NotFound(w, r)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode([]*Line{
+			{Out: "go test ./...\n", Pos: 0},
+			{Out: "FAIL\n", Pos: 1},
+		})
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestClientBuild(t *testing.T) {
+	server := newTestServer(t, "secret")
+
+	build, err := New(server.URL+"/", "secret", nil).Build(context.Background(), "volker.raschek", "drone-email", 42)
+	if err != nil {
+		t.Fatalf("failed to fetch build: %v", err)
+	}
+
+	if build.Number != 42 || len(build.Stages) != 1 || len(build.Stages[0].Steps) != 1 {
+		t.Fatalf("unexpected build %+v", build)
+	}
+	if step := build.Stages[0].Steps[0]; step.Name != "test" || step.ExitCode != 1 {
+		t.Errorf("unexpected step %+v", step)
+	}
+}
+
+func TestClientLogs(t *testing.T) {
+	server := newTestServer(t, "secret")
+
+	lines, err := New(server.URL, "secret", nil).Logs(context.Background(), "volker.raschek", "drone-email", 42, 1, 2)
+	if err != nil {
+		t.Fatalf("failed to fetch logs: %v", err)
+	}
+
+	if len(lines) != 2 || lines[1].Out != "FAIL\n" {
+		t.Errorf("unexpected lines %+v", lines)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	server := newTestServer(t, "secret")
+
+	testCases := []struct {
+		name  string
+		token string
+		build int
+	}{
+		{name: "unauthorized", token: "invalid", build: 42},
+		{name: "not found", token: "secret", build: 43},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := New(server.URL, testCase.token, nil).Build(context.Background(), "volker.raschek", "drone-email", testCase.build)
+			if err == nil {
+				t.Error("expected an error of a non-200 response")
+			}
+		})
+	}
+}
+
+func TestClientInvalidResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html>"))
+	}))
+	t.Cleanup(server.Close)
+
+	_, err := New(server.URL, "secret", nil).Build(context.Background(), "volker.raschek", "drone-email", 42)
+	if err == nil {
+		t.Error("expected an error of an invalid response")
+	}
+}
+
+func TestTail(t *testing.T) {
+	lines := []*Line{
+		{Out: "go test ./...\n"},
+		{Out: "--- FAIL: TestTail\n    tail_test.go:1: failed\r\n"},
+		{Out: "FAIL\n"},
+	}
+
+	testCases := []struct {
+		name     string
+		n        int
+		expected []string
+	}{
+		{name: "none", n: 0, expected: []string{}},
+		{name: "last", n: 2, expected: []string{"    tail_test.go:1: failed", "FAIL"}},
+		{name: "more than available", n: 10, expected: []string{"go test ./...", "--- FAIL: TestTail", "    tail_test.go:1: failed", "FAIL"}},
+		{name: "all", n: -1, expected: []string{"go test ./...", "--- FAIL: TestTail", "    tail_test.go:1: failed", "FAIL"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := Tail(lines, testCase.n)
+			if !slices.Equal(actual, testCase.expected) {
+				t.Errorf("expected %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package expr
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+type kind int
+
+const (
+	kindBool kind = iota + 1
+	kindInt
+	kindString
+	kindList
+)
+
+func (k kind) String() string {
+	switch k {
+	case kindBool:
+		return "bool"
+	case kindInt:
+		return "int"
+	case kindString:
+		return "string"
+	case kindList:
+		return "list"
+	default:
+		return "unknown"
+	}
+}
+
+// variable is a field of the environment, which can be reached from the root
+// struct by the field indices.
+type variable struct {
+	kind  kind
+	index [][]int
+}
+
+// lookup returns the value of the variable. Nil pointers on the way to the
+// field result in the zero value of the variable kind.
+func (v *variable) lookup(root reflect.Value) any {
+	value := root
+	for _, index := range v.index {
+		for value.Kind() == reflect.Pointer {
+			if value.IsNil() {
+				return v.zero()
+			}
+			value = value.Elem()
+		}
+		value = value.FieldByIndex(index)
+	}
+
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return v.zero()
+		}
+		value = value.Elem()
+	}
+
+	switch v.kind {
+	case kindBool:
+		return value.Bool()
+	case kindInt:
+		if value.CanUint() {
+			// #nosec G115
+			return int64(value.Uint())
+		}
+		return value.Int()
+	default:
+		return value.String()
+	}
+}
+
+func (v *variable) zero() any {
+	switch v.kind {
+	case kindBool:
+		return false
+	case kindInt:
+		return int64(0)
+	default:
+		return ""
+	}
+}
+
+// Env describes the variables of a struct type, which are accessible by
+// expressions. Variables are the exported bool, integer and string fields of the
+// struct and its nested structs, named by their snake case field names joined
+// by dots, for example commit.author.email. Fields of embedded structs are
+// promoted. Methods, maps, slices and all other types are not accessible.
+type Env struct {
+	rootType  reflect.Type
+	variables map[string]*variable
+}
+
+func (e *Env) collect(t reflect.Type, prefix string, index [][]int, visited map[reflect.Type]bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || visited[t] {
+		return
+	}
+	visited[t] = true
+	defer delete(visited, t)
+
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+
+		name := prefix + snakeCase(field.Name)
+		fieldIndex := append(append(make([][]int, 0, len(index)+1), index...), field.Index)
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+
+		switch fieldType.Kind() {
+		case reflect.Bool:
+			e.variables[name] = &variable{kind: kindBool, index: fieldIndex}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+			e.variables[name] = &variable{kind: kindInt, index: fieldIndex}
+		case reflect.String:
+			e.variables[name] = &variable{kind: kindString, index: fieldIndex}
+		case reflect.Struct:
+			e.collect(fieldType, name+".", fieldIndex, visited)
+		}
+	}
+}
+
+// NewEnv returns the environment of the struct type of v. V can be a nil
+// pointer of the struct type.
+func NewEnv(v any) *Env {
+	e := &Env{
+		rootType:  reflect.TypeOf(v),
+		variables: make(map[string]*variable),
+	}
+	e.collect(e.rootType, "", nil, make(map[reflect.Type]bool))
+
+	return e
+}
+
+// snakeCase converts a Go field name like FullName or SCMURL into full_name or
+// scmurl.
+func snakeCase(s string) string {
+	runes := []rune(s)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (nextLower && unicode.IsUpper(runes[i-1])) {
+				sb.WriteRune('_')
+			}
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return sb.String()
+}
@@ -0,0 +1,34 @@
+package expr
+
+import "fmt"
+
+// SyntaxError is returned by Compile, when the expression can not be parsed.
+type SyntaxError struct {
+	// Column is the 1-based position in the expression.
+	Column int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at column %d: %s", e.Column, e.Msg)
+}
+
+// TypeError is returned by Compile, when the expression refers to unknown
+// variables or the types of the operands do not match.
+type TypeError struct {
+	// Column is the 1-based position in the expression.
+	Column int
+	Msg    string
+}
+
+func (e *TypeError) Error() string {
+	return fmt.Sprintf("type error at column %d: %s", e.Column, e.Msg)
+}
+
+func newSyntaxError(pos int, format string, a ...any) error {
+	return &SyntaxError{Column: pos + 1, Msg: fmt.Sprintf(format, a...)}
+}
+
+func newTypeError(pos int, format string, a ...any) error {
+	return &TypeError{Column: pos + 1, Msg: fmt.Sprintf(format, a...)}
+}
@@ -0,0 +1,382 @@
+// Package expr implements a small expression language to evaluate conditions
+// against the fields of a struct, for example
+//
+//	build.status != "success" && commit.branch matches "^release/"
+//
+// Expressions support the literals true, false, integers, strings and lists
+// like ["push", "tag"], the comparison operators ==, !=, <, <=, > and >=, the
+// string operators matches (regular expression), contains and in (list
+// membership) as well as the logical operators !, && and ||. Expressions are
+// type checked by Compile and can only access the variables of their Env.
+package expr
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// node is a type checked part of the expression.
+type node struct {
+	kind kind
+	pos  int
+	eval func(root reflect.Value) any
+
+	// literal is true, if the node is a literal, whose value is known at compile
+	// time.
+	literal bool
+}
+
+type Program struct {
+	env    *Env
+	root   *node
+	source string
+}
+
+// Eval evaluates the program against v, which must be of the struct type of the
+// environment the program has been compiled for.
+func (p *Program) Eval(v any) (bool, error) {
+	value := reflect.ValueOf(v)
+	if value.Type() != p.env.rootType {
+		return false, fmt.Errorf("expected value of type %s, got %s", p.env.rootType, value.Type())
+	}
+
+	return p.root.eval(value).(bool), nil
+}
+
+func (p *Program) String() string {
+	return p.source
+}
+
+// Compile parses and type checks the expression. The expression must evaluate
+// to a bool.
+func Compile(source string, env *Env) (*Program, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+
+	ps := &parser{
+		env:    env,
+		tokens: tokens,
+	}
+
+	root, err := ps.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := ps.peek(); t.kind != tokenEOF {
+		return nil, newSyntaxError(t.pos, "unexpected %s", t)
+	}
+
+	if root.kind != kindBool {
+		return nil, newTypeError(root.pos, "expression evaluates to %s, expected bool", root.kind)
+	}
+
+	return &Program{
+		env:    env,
+		root:   root,
+		source: source,
+	}, nil
+}
+
+type parser struct {
+	env    *Env
+	tokens []token
+	pos    int
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) isOperator(operators ...string) bool {
+	t := p.peek()
+	return (t.kind == tokenOperator || t.kind == tokenIdent) && slices.Contains(operators, t.text)
+}
+
+func (p *parser) parseOr() (*node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isOperator("||") {
+		operator := p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left, err = logical(operator, left, right)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (*node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isOperator("&&") {
+		operator := p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left, err = logical(operator, left, right)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseNot() (*node, error) {
+	if !p.isOperator("!") {
+		return p.parseComparison()
+	}
+
+	operator := p.next()
+	operand, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	if operand.kind != kindBool {
+		return nil, newTypeError(operand.pos, "operator ! expects bool, got %s", operand.kind)
+	}
+
+	return &node{
+		kind: kindBool,
+		pos:  operator.pos,
+		eval: func(root reflect.Value) any {
+			return !operand.eval(root).(bool)
+		},
+	}, nil
+}
+
+func (p *parser) parseComparison() (*node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.isOperator("==", "!=", "<", "<=", ">", ">=", "matches", "contains", "in") {
+		return left, nil
+	}
+
+	operator := p.next()
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	return compare(operator, left, right)
+}
+
+func (p *parser) parsePrimary() (*node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenLParen:
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, newSyntaxError(closing.pos, "expected \")\", got %s", closing)
+		}
+		return n, nil
+	case tokenLBracket:
+		return p.parseList(t)
+	case tokenInt, tokenString:
+		return literal(t), nil
+	case tokenIdent:
+		switch t.text {
+		case "true", "false":
+			return newLiteral(kindBool, t.pos, t.text == "true"), nil
+		case "matches", "contains", "in":
+			return nil, newSyntaxError(t.pos, "unexpected operator %s", t)
+		}
+
+		v, ok := p.env.variables[t.text]
+		if !ok {
+			return nil, newTypeError(t.pos, "unknown variable %s", t.text)
+		}
+		return &node{kind: v.kind, pos: t.pos, eval: v.lookup}, nil
+	default:
+		return nil, newSyntaxError(t.pos, "unexpected %s", t)
+	}
+}
+
+// parseList parses a list of literals of the same type, for example
+// ["push", "tag"].
+func (p *parser) parseList(opening token) (*node, error) {
+	values := make([]any, 0)
+	var elementKind kind
+	for p.peek().kind != tokenRBracket {
+		if len(values) > 0 {
+			if comma := p.next(); comma.kind != tokenComma {
+				return nil, newSyntaxError(comma.pos, "expected \",\" or \"]\", got %s", comma)
+			}
+		}
+
+		t := p.next()
+		if t.kind != tokenInt && t.kind != tokenString {
+			return nil, newSyntaxError(t.pos, "expected string or integer list element, got %s", t)
+		}
+
+		element := literal(t)
+		if len(values) > 0 && element.kind != elementKind {
+			return nil, newTypeError(t.pos, "list element of type %s, expected %s", element.kind, elementKind)
+		}
+		elementKind = element.kind
+		values = append(values, t.value)
+	}
+	p.next()
+
+	return newLiteral(kindList, opening.pos, values), nil
+}
+
+func compare(operator token, left *node, right *node) (*node, error) {
+	newNode := func(f func(l any, r any) bool) *node {
+		return &node{
+			kind: kindBool,
+			pos:  left.pos,
+			eval: func(root reflect.Value) any {
+				return f(left.eval(root), right.eval(root))
+			},
+		}
+	}
+
+	switch operator.text {
+	case "==", "!=":
+		if left.kind != right.kind || left.kind == kindList {
+			return nil, newTypeError(operator.pos, "operator %s can not compare %s with %s", operator.text, left.kind, right.kind)
+		}
+		equal := operator.text == "=="
+		return newNode(func(l any, r any) bool { return (l == r) == equal }), nil
+
+	case "<", "<=", ">", ">=":
+		if left.kind != right.kind || (left.kind != kindInt && left.kind != kindString) {
+			return nil, newTypeError(operator.pos, "operator %s can not compare %s with %s", operator.text, left.kind, right.kind)
+		}
+		return newNode(func(l any, r any) bool {
+			var c int
+			if left.kind == kindInt {
+				c = compareInt(l.(int64), r.(int64))
+			} else {
+				c = strings.Compare(l.(string), r.(string))
+			}
+			switch operator.text {
+			case "<":
+				return c < 0
+			case "<=":
+				return c <= 0
+			case ">":
+				return c > 0
+			default:
+				return c >= 0
+			}
+		}), nil
+
+	case "matches":
+		if left.kind != kindString || right.kind != kindString {
+			return nil, newTypeError(operator.pos, "operator matches expects string operands, got %s and %s", left.kind, right.kind)
+		}
+		if !right.literal {
+			return nil, newTypeError(right.pos, "operator matches expects a string literal as regular expression")
+		}
+		re, err := regexp.Compile(right.eval(reflect.Value{}).(string))
+		if err != nil {
+			return nil, newSyntaxError(right.pos, "invalid regular expression: %v", err)
+		}
+		return newNode(func(l any, _ any) bool { return re.MatchString(l.(string)) }), nil
+
+	case "contains":
+		if left.kind != kindString || right.kind != kindString {
+			return nil, newTypeError(operator.pos, "operator contains expects string operands, got %s and %s", left.kind, right.kind)
+		}
+		return newNode(func(l any, r any) bool { return strings.Contains(l.(string), r.(string)) }), nil
+
+	case "in":
+		if right.kind != kindList {
+			return nil, newTypeError(operator.pos, "operator in expects a list, got %s", right.kind)
+		}
+		values := right.eval(reflect.Value{}).([]any)
+		if len(values) > 0 && literalKind(values[0]) != left.kind {
+			return nil, newTypeError(operator.pos, "operator in can not compare %s with list of %s", left.kind, literalKind(values[0]))
+		}
+		return newNode(func(l any, _ any) bool { return slices.Contains(values, l) }), nil
+	}
+
+	return nil, newSyntaxError(operator.pos, "unknown operator %s", operator)
+}
+
+func logical(operator token, left *node, right *node) (*node, error) {
+	if left.kind != kindBool || right.kind != kindBool {
+		return nil, newTypeError(operator.pos, "operator %s expects bool operands, got %s and %s", operator.text, left.kind, right.kind)
+	}
+
+	and := operator.text == "&&"
+	return &node{
+		kind: kindBool,
+		pos:  left.pos,
+		eval: func(root reflect.Value) any {
+			if and {
+				return left.eval(root).(bool) && right.eval(root).(bool)
+			}
+			return left.eval(root).(bool) || right.eval(root).(bool)
+		},
+	}, nil
+}
+
+func literal(t token) *node {
+	return newLiteral(literalKind(t.value), t.pos, t.value)
+}
+
+func literalKind(v any) kind {
+	switch v.(type) {
+	case int64:
+		return kindInt
+	case bool:
+		return kindBool
+	default:
+		return kindString
+	}
+}
+
+func newLiteral(k kind, pos int, v any) *node {
+	return &node{
+		kind:    k,
+		pos:     pos,
+		eval:    func(reflect.Value) any { return v },
+		literal: true,
+	}
+}
+
+func compareInt(a int64, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,206 @@
+package expr_test
+
+import (
+	"errors"
+	"testing"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/domain"
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/expr"
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/mail"
+)
+
+func newCIVars() *mail.CIVars {
+	ciVars := mail.NewCIVars()
+	ciVars.Build.Event = "push"
+	ciVars.Build.Number = 42
+	ciVars.Build.Status = domain.StatusFailure
+	ciVars.Commit.Author.Email = "max.mustermann@example.com"
+	ciVars.Commit.Branch = "release/1.2"
+	ciVars.Commit.Message = "fix: handle empty recipients"
+	ciVars.DeployTo = "production"
+	ciVars.Repo.FullName = "volker.raschek/drone-email-docker"
+	ciVars.Repo.Private = true
+	return ciVars
+}
+
+func TestEval(t *testing.T) {
+	testCases := []struct {
+		source   string
+		expected bool
+	}{
+		{source: `true`, expected: true},
+		{source: `false`, expected: false},
+		{source: `build.status == "failure"`, expected: true},
+		{source: `build.status == 'success'`, expected: false},
+		{source: `build.status != "success"`, expected: true},
+		{source: `build.status != "failure"`, expected: false},
+		{source: `build.number == 42`, expected: true},
+		{source: `build.number != 42`, expected: false},
+		{source: `build.number < 43`, expected: true},
+		{source: `build.number < 42`, expected: false},
+		{source: `build.number <= 42`, expected: true},
+		{source: `build.number <= 41`, expected: false},
+		{source: `build.number > 41`, expected: true},
+		{source: `build.number > 42`, expected: false},
+		{source: `build.number >= 42`, expected: true},
+		{source: `build.number >= 43`, expected: false},
+		{source: `build.number > -1`, expected: true},
+		{source: `deploy_to < "staging"`, expected: true},
+		{source: `deploy_to >= "staging"`, expected: false},
+		{source: `commit.branch matches "^release/"`, expected: true},
+		{source: `commit.branch matches "^main$"`, expected: false},
+		{source: `commit.message contains "recipients"`, expected: true},
+		{source: `commit.message contains "feat"`, expected: false},
+		{source: `commit.author.email contains "@example.com"`, expected: true},
+		{source: `build.event in ["push", "tag"]`, expected: true},
+		{source: `build.event in ["cron"]`, expected: false},
+		{source: `build.event in []`, expected: false},
+		{source: `build.number in [1, 42]`, expected: true},
+		{source: `repo.private`, expected: true},
+		{source: `repo.private == false`, expected: false},
+		{source: `repo.trusted`, expected: false},
+		{source: `!repo.private`, expected: false},
+		{source: `!!repo.private`, expected: true},
+		{source: `repo.private && build.number == 42`, expected: true},
+		{source: `repo.private && build.number == 1`, expected: false},
+		{source: `repo.trusted || build.number == 42`, expected: true},
+		{source: `repo.trusted || build.number == 1`, expected: false},
+		{source: `repo.trusted && false || true`, expected: true},
+		{source: `repo.trusted && (false || true)`, expected: false},
+		{source: `!(build.status == "success") && repo.full_name == "volker.raschek/drone-email-docker"`, expected: true},
+		{source: `commit.message == "fix: handle empty recipients"`, expected: true},
+		{source: `"a\"b" contains "\""`, expected: true},
+		{source: `"a\tb" == 'a\tb'`, expected: true},
+	}
+
+	env := expr.NewEnv(&mail.CIVars{})
+	ciVars := newCIVars()
+
+	for _, testCase := range testCases {
+		t.Run(testCase.source, func(t *testing.T) {
+			program, err := expr.Compile(testCase.source, env)
+			if err != nil {
+				t.Fatalf("failed to compile expression: %v", err)
+			}
+
+			actual, err := program.Eval(ciVars)
+			if err != nil {
+				t.Fatalf("failed to evaluate expression: %v", err)
+			}
+
+			if actual != testCase.expected {
+				t.Errorf("expected %v, got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestEvalNilPointer(t *testing.T) {
+	program, err := expr.Compile(`commit.author.email == "" && build.number == 0`, expr.NewEnv(&mail.CIVars{}))
+	if err != nil {
+		t.Fatalf("failed to compile expression: %v", err)
+	}
+
+	actual, err := program.Eval(&mail.CIVars{})
+	if err != nil {
+		t.Fatalf("failed to evaluate expression: %v", err)
+	}
+	if !actual {
+		t.Error("expected nil pointers to evaluate to zero values")
+	}
+}
+
+func TestEvalWrongType(t *testing.T) {
+	program, err := expr.Compile(`true`, expr.NewEnv(&mail.CIVars{}))
+	if err != nil {
+		t.Fatalf("failed to compile expression: %v", err)
+	}
+
+	_, err = program.Eval(mail.CIVars{})
+	if err == nil {
+		t.Error("expected an error of a value of another type")
+	}
+}
+
+func TestCompileSyntaxError(t *testing.T) {
+	testCases := []struct {
+		source string
+		column int
+	}{
+		{source: ``, column: 1},
+		{source: `build.status == "success`, column: 17},
+		{source: `build.status == "success\`, column: 25},
+		{source: `build.status == "\x"`, column: 18},
+		{source: `build.status = "success"`, column: 14},
+		{source: `build.status == "success" &`, column: 27},
+		{source: `build.number == 99999999999999999999`, column: 17},
+		{source: `(repo.private`, column: 14},
+		{source: `repo.private)`, column: 13},
+		{source: `repo.private repo.trusted`, column: 14},
+		{source: `build.event in ["push" "tag"]`, column: 24},
+		{source: `build.event in ["push", true]`, column: 25},
+		{source: `build.event in ["push"`, column: 23},
+		{source: `build.status ==`, column: 16},
+		{source: `matches "^release/"`, column: 1},
+		{source: `commit.branch matches "["`, column: 23},
+	}
+
+	env := expr.NewEnv(&mail.CIVars{})
+
+	for _, testCase := range testCases {
+		t.Run(testCase.source, func(t *testing.T) {
+			_, err := expr.Compile(testCase.source, env)
+
+			var syntaxError *expr.SyntaxError
+			if !errors.As(err, &syntaxError) {
+				t.Fatalf("expected syntax error, got %v", err)
+			}
+			if syntaxError.Column != testCase.column {
+				t.Errorf("expected column %d, got %d: %v", testCase.column, syntaxError.Column, err)
+			}
+		})
+	}
+}
+
+func TestCompileTypeError(t *testing.T) {
+	testCases := []struct {
+		source string
+		column int
+	}{
+		{source: `build.number == "x"`, column: 14},
+		{source: `build.status == 1`, column: 14},
+		{source: `build.unknown == "x"`, column: 1},
+		{source: `unknown`, column: 1},
+		{source: `commit.author == "x"`, column: 1},
+		{source: `failed_steps contains "build"`, column: 1},
+		{source: `build.status`, column: 1},
+		{source: `build.number`, column: 1},
+		{source: `!build.status`, column: 2},
+		{source: `repo.private && build.number`, column: 14},
+		{source: `build.status || repo.private`, column: 14},
+		{source: `repo.private < true`, column: 14},
+		{source: `["push"] == ["push"]`, column: 10},
+		{source: `build.number matches "^4"`, column: 14},
+		{source: `build.status matches deploy_to`, column: 22},
+		{source: `build.number contains "4"`, column: 14},
+		{source: `build.event in "push"`, column: 13},
+		{source: `build.number in ["42"]`, column: 14},
+		{source: `build.event in ["push", 1]`, column: 25},
+	}
+
+	env := expr.NewEnv(&mail.CIVars{})
+
+	for _, testCase := range testCases {
+		t.Run(testCase.source, func(t *testing.T) {
+			_, err := expr.Compile(testCase.source, env)
+
+			var typeError *expr.TypeError
+			if !errors.As(err, &typeError) {
+				t.Fatalf("expected type error, got %v", err)
+			}
+			if typeError.Column != testCase.column {
+				t.Errorf("expected column %d, got %d: %v", testCase.column, typeError.Column, err)
+			}
+		})
+	}
+}
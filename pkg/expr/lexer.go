@@ -0,0 +1,141 @@
+package expr
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenInt
+	tokenString
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenLBracket
+	tokenRBracket
+	tokenComma
+)
+
+type token struct {
+	kind  tokenKind
+	pos   int
+	text  string
+	value any
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!"}
+
+// lex splits the source into tokens.
+func lex(source string) ([]token, error) {
+	tokens := make([]token, 0)
+	runes := []rune(source)
+
+	for pos := 0; pos < len(runes); {
+		r := runes[pos]
+		switch {
+		case unicode.IsSpace(r):
+			pos++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, pos: pos, text: "("})
+			pos++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, pos: pos, text: ")"})
+			pos++
+		case r == '[':
+			tokens = append(tokens, token{kind: tokenLBracket, pos: pos, text: "["})
+			pos++
+		case r == ']':
+			tokens = append(tokens, token{kind: tokenRBracket, pos: pos, text: "]"})
+			pos++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, pos: pos, text: ","})
+			pos++
+		case r == '"' || r == '\'':
+			end, value, err := lexString(runes, pos)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, pos: pos, text: string(runes[pos:end]), value: value})
+			pos = end
+		case unicode.IsDigit(r) || r == '-' && pos+1 < len(runes) && unicode.IsDigit(runes[pos+1]):
+			end := pos + 1
+			for end < len(runes) && unicode.IsDigit(runes[end]) {
+				end++
+			}
+			value, err := strconv.ParseInt(string(runes[pos:end]), 10, 64)
+			if err != nil {
+				return nil, newSyntaxError(pos, "invalid number %s", string(runes[pos:end]))
+			}
+			tokens = append(tokens, token{kind: tokenInt, pos: pos, text: string(runes[pos:end]), value: value})
+			pos = end
+		case unicode.IsLetter(r) || r == '_':
+			end := pos + 1
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '_' || runes[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, pos: pos, text: string(runes[pos:end])})
+			pos = end
+		default:
+			operator := ""
+			for _, o := range operators {
+				if strings.HasPrefix(string(runes[pos:]), o) {
+					operator = o
+					break
+				}
+			}
+			if len(operator) <= 0 {
+				return nil, newSyntaxError(pos, "unexpected character %q", r)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, pos: pos, text: operator})
+			pos += len([]rune(operator))
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokenEOF, pos: len(runes)})
+
+	return tokens, nil
+}
+
+// lexString returns the end position and the unquoted value of the string
+// literal starting at pos.
+func lexString(runes []rune, pos int) (int, string, error) {
+	quote := runes[pos]
+	var sb strings.Builder
+	for i := pos + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case quote:
+			return i + 1, sb.String(), nil
+		case '\\':
+			if i+1 >= len(runes) {
+				return 0, "", newSyntaxError(i, "unterminated escape sequence")
+			}
+			i++
+			switch runes[i] {
+			case 'n':
+				sb.WriteRune('\n')
+			case 't':
+				sb.WriteRune('\t')
+			case '\\', '"', '\'':
+				sb.WriteRune(runes[i])
+			default:
+				return 0, "", newSyntaxError(i-1, "unknown escape sequence \\%c", runes[i])
+			}
+		default:
+			sb.WriteRune(runes[i])
+		}
+	}
+
+	return 0, "", newSyntaxError(pos, "unterminated string")
+}
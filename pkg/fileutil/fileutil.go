@@ -0,0 +1,44 @@
+package fileutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WriteFile writes the data into a temporary file first and renames it to name
+// afterwards, so that readers never see an incomplete file. The directory of
+// the file is created if it does not exist.
+func WriteFile(name string, data []byte) error {
+	dir := filepath.Dir(name)
+	err := os.MkdirAll(dir, 0o750)
+	if err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	f, err := os.CreateTemp(dir, filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+
+	_, err = f.Write(data)
+	if err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("failed to write %s: %w", f.Name(), err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("failed to close %s: %w", f.Name(), err)
+	}
+
+	err = os.Rename(f.Name(), name)
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("failed to rename %s: %w", f.Name(), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package fileutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	lockFileExtension = ".lock"
+	lockPollInterval  = 50 * time.Millisecond
+	lockTimeout       = 30 * time.Second
+
+	// lockStaleAfter is the age after which a lock file is considered to be left
+	// behind by a crashed process. Locks are only held while reading and writing
+	// a file, which takes far less time.
+	lockStaleAfter = 10 * time.Second
+)
+
+// Lock creates the lock file of the file name exclusively and returns a
+// function to remove it again. The lock file is named like the file with the
+// extension .lock. If the lock file already exists, Lock waits until it has
+// been removed or is stale. The directory of the file is created if it does not
+// exist.
+func Lock(name string) (func() error, error) {
+	dir := filepath.Dir(name)
+	err := os.MkdirAll(dir, 0o750)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	lockName := name + lockFileExtension
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		// #nosec G304
+		f, err := os.OpenFile(lockName, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			err = f.Close()
+			if err != nil {
+				_ = os.Remove(lockName)
+				return nil, fmt.Errorf("failed to close %s: %w", lockName, err)
+			}
+
+			return func() error {
+				err := os.Remove(lockName)
+				if err != nil {
+					return fmt.Errorf("failed to remove %s: %w", lockName, err)
+				}
+				return nil
+			}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to create %s: %w", lockName, err)
+		}
+
+		fileInfo, err := os.Stat(lockName)
+		if err == nil && time.Since(fileInfo.ModTime()) > lockStaleAfter {
+			_ = os.Remove(lockName)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("failed to lock %s: timeout after %s", name, lockTimeout)
+		}
+
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// WithLock locks the file name, calls f and unlocks the file afterwards. The
+// error of f takes precedence over the error of unlocking the file.
+func WithLock(name string, f func() error) error {
+	unlock, err := Lock(name)
+	if err != nil {
+		return err
+	}
+
+	err = f()
+	if unlockErr := unlock(); unlockErr != nil && err == nil {
+		err = unlockErr
+	}
+
+	return err
+}
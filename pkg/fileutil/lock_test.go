@@ -0,0 +1,72 @@
+package fileutil
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithLock(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "counter")
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	holders := 0
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := WithLock(name, func() error {
+				mu.Lock()
+				holders++
+				n := holders
+				mu.Unlock()
+				if n > 1 {
+					t.Errorf("expected a single holder of the lock, got %d", n)
+				}
+
+				time.Sleep(time.Millisecond)
+
+				mu.Lock()
+				holders--
+				mu.Unlock()
+				return nil
+			})
+			if err != nil {
+				t.Errorf("failed to lock %s: %v", name, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	_, err := os.Stat(name + lockFileExtension)
+	if !os.IsNotExist(err) {
+		t.Errorf("expected lock file to be removed, got %v", err)
+	}
+}
+
+func TestLockStale(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "state.json")
+
+	err := os.WriteFile(name+lockFileExtension, nil, 0o600)
+	if err != nil {
+		t.Fatalf("failed to write lock file: %v", err)
+	}
+	stale := time.Now().Add(-2 * lockStaleAfter)
+	err = os.Chtimes(name+lockFileExtension, stale, stale)
+	if err != nil {
+		t.Fatalf("failed to change time of lock file: %v", err)
+	}
+
+	unlock, err := Lock(name)
+	if err != nil {
+		t.Fatalf("failed to lock %s with stale lock: %v", name, err)
+	}
+
+	err = unlock()
+	if err != nil {
+		t.Errorf("failed to unlock %s: %v", name, err)
+	}
+}
@@ -1,56 +1,105 @@
 package flags
 
 const (
-	DRONE_BUILD_CREATED        string = "drone-build-created"
-	DRONE_BUILD_EVENT          string = "drone-build-event"
-	DRONE_BUILD_FINISHED       string = "drone-build-finished"
-	DRONE_BUILD_LINK           string = "drone-build-link"
-	DRONE_BUILD_NUMBER         string = "drone-build-number"
-	DRONE_BUILD_STARTED        string = "drone-build-started"
-	DRONE_BUILD_STATUS         string = "drone-build-status"
-	DRONE_COMMIT_AUTHOR_NAME   string = "drone-commit-author-name"
-	DRONE_COMMIT_AUTHOR_AVATAR string = "drone-commit-author-avatar"
-	DRONE_COMMIT_AUTHOR_EMAIL  string = "drone-commit-author-email"
-	DRONE_COMMIT_BRANCH        string = "drone-commit-branch"
-	DRONE_COMMIT_LINK          string = "drone-commit-link"
-	DRONE_COMMIT_MESSAGE       string = "drone-commit-message"
-	DRONE_COMMIT_REF           string = "drone-commit-ref"
-	DRONE_COMMIT_SHA           string = "drone-commit-sha"
-	DRONE_DEPLOY_TO            string = "drone-deploy-to"
-	DRONE_JOB_EXIT_CODE        string = "drone-job-exit-code"
-	DRONE_JOB_FINISHED         string = "drone-job-finished"
-	DRONE_JOB_NUMBER           string = "drone-job-number"
-	DRONE_JOB_STARTED          string = "drone-job-started"
-	DRONE_JOB_STATUS           string = "drone-job-status"
-	DRONE_PREV_BUILD_NUMBER    string = "drone-prev-build-number"
-	DRONE_PREV_BUILD_STATUS    string = "drone-prev-build-status"
-	DRONE_PREV_COMMIT_SHA      string = "drone-prev-commit-sha"
-	DRONE_PULL_REQUEST         string = "drone-pull-request"
-	DRONE_REMOTE_URL           string = "drone-remote-url"
-	DRONE_REPO                 string = "drone-repo"
-	DRONE_REPO_AVATAR          string = "drone-repo-avatar"
-	DRONE_REPO_BRANCH          string = "drone-repo-branch"
-	DRONE_REPO_LINK            string = "drone-repo-link"
-	DRONE_REPO_NAME            string = "drone-repo-name"
-	DRONE_REPO_OWNER           string = "drone-repo-owner"
-	DRONE_REPO_PRIVATE         string = "drone-repo-private"
-	DRONE_REPO_SCM             string = "drone-repo-scm"
-	DRONE_REPO_TRUSTED         string = "drone-repo-trusted"
-	DRONE_TAG                  string = "drone-tag"
-	DRONE_YAML_SIGNED          string = "drone-yaml-signed"
-	DRONE_YAML_VERIFIED        string = "drone-yaml-verified"
+	CI_PROVIDER string = "ci-provider"
 )
 
 const (
+	CONTEXT_FILE string = "context-file"
+)
+
+const (
+	DATA_FILE string = "data-file"
+)
+
+const (
+	DEPLOY_HISTORY_FILE string = "deploy-history-file"
+	DEPLOY_RECIPIENTS   string = "deploy-recipients"
+)
+
+const (
+	DIGEST_SPOOL string = "digest-spool"
+)
+
+const (
+	DRONE_LOG_LINES string = "drone-log-lines"
+	DRONE_SERVER    string = "drone-server"
+	DRONE_TOKEN     string = "drone-token"
+)
+
+const (
+	DRY_RUN      string = "dry-run"
+	DRY_RUN_SMTP string = "dry-run-smtp"
+)
+
+const (
+	ESCALATION_HISTORY_FILE string = "escalation-history-file"
+	ESCALATION_LEVELS       string = "escalation-levels"
+)
+
+const (
+	FAILURE_POLICY string = "failure-policy"
+)
+
+const (
+	NOTIFY_CONDITION         string = "notify-condition"
+	NOTIFY_EXCLUDE_BRANCHES  string = "notify-exclude-branches"
+	NOTIFY_EXCLUDE_DEPLOY_TO string = "notify-exclude-deploy-to"
+	NOTIFY_EXCLUDE_EVENTS    string = "notify-exclude-events"
+	NOTIFY_EXCLUDE_REPOS     string = "notify-exclude-repos"
+	NOTIFY_EXCLUDE_STATUSES  string = "notify-exclude-statuses"
+	NOTIFY_EXCLUDE_TAGS      string = "notify-exclude-tags"
+	NOTIFY_INCLUDE_BRANCHES  string = "notify-include-branches"
+	NOTIFY_INCLUDE_DEPLOY_TO string = "notify-include-deploy-to"
+	NOTIFY_INCLUDE_EVENTS    string = "notify-include-events"
+	NOTIFY_INCLUDE_REPOS     string = "notify-include-repos"
+	NOTIFY_INCLUDE_STATUSES  string = "notify-include-statuses"
+	NOTIFY_INCLUDE_TAGS      string = "notify-include-tags"
+	NOTIFY_MODE              string = "notify-mode"
+	NOTIFY_WHEN              string = "notify-when"
+)
+
+const (
+	SCHEDULE_ACTION          string = "schedule-action"
+	SCHEDULE_HOLIDAY_FILE    string = "schedule-holiday-file"
+	SCHEDULE_ON_CALL_ADDRESS string = "schedule-on-call-address"
+	SCHEDULE_SPOOL_DIR       string = "schedule-spool-dir"
+	SCHEDULE_TIMEZONE        string = "schedule-timezone"
+	SCHEDULE_WEEKDAYS        string = "schedule-weekdays"
+	SCHEDULE_WINDOWS         string = "schedule-windows"
+)
+
+const (
+	STATE_FILE   string = "state-file"
+	STATE_WINDOW string = "state-window"
+)
+
+const (
+	SMTP_ALLOW_LIST               string = "smtp-allow-list"
+	SMTP_ALLOW_LIST_PRIVATE_ONLY  string = "smtp-allow-list-private-only"
+	SMTP_DENY_LIST                string = "smtp-deny-list"
+	SMTP_DIRECTORY_FILE           string = "smtp-directory-file"
+	SMTP_FALLBACK_ADDRESS         string = "smtp-fallback-address"
 	SMTP_FROM_ADDRESS             string = "smtp-from-address"
 	SMTP_FROM_NAME                string = "smtp-from-name"
 	SMTP_HELO                     string = "smtp-helo"
 	SMTP_HOST                     string = "smtp-host"
+	SMTP_LIST_UNSUBSCRIBE         string = "smtp-list-unsubscribe"
 	SMTP_MAIL_SUBJECT             string = "smtp-mail-subject"
+	SMTP_OPT_OUT_FILE             string = "smtp-opt-out-file"
 	SMTP_PASSWORD                 string = "smtp-password"
 	SMTP_PORT                     string = "smtp-port"
 	SMTP_START_TLS                string = "smtp-no-start-tls"
 	SMTP_TLS_INSECURE_SKIP_VERIFY string = "smtp-tls-insecure"
 	SMTP_TO_ADDRESSES             string = "smtp-to-addresses"
+	SMTP_TO_CO_AUTHORS            string = "smtp-to-co-authors"
+	SMTP_TO_CONDITIONAL           string = "smtp-to-conditional"
+	SMTP_TO_PULL_REQUEST_AUTHOR   string = "smtp-to-pull-request-author"
+	SMTP_TO_TRAILERS              string = "smtp-to-trailers"
 	SMTP_USERNAME                 string = "smtp-username"
 )
+
+const (
+	VAR  string = "var"
+	VARS string = "vars"
+)
@@ -0,0 +1,251 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/fileutil"
+)
+
+// Entry is the build history of a branch of a repository.
+type Entry struct {
+	Branch string `json:"branch"`
+
+	// Build is the number of the last recorded build. Restarted builds are not
+	// counted twice.
+	Build int `json:"build"`
+
+	// Failures is the number of consecutive failed builds.
+	Failures int `json:"failures"`
+
+	Repo    string    `json:"repo"`
+	Updated time.Time `json:"updated"`
+}
+
+// maxCommits is the maximum number of commits recorded per repository. Older
+// commits are dropped.
+const maxCommits = 250
+
+// Commit is a built commit of a repository.
+type Commit struct {
+	Author  string    `json:"author"`
+	Branch  string    `json:"branch"`
+	Built   time.Time `json:"built"`
+	Link    string    `json:"link"`
+	Repo    string    `json:"repo"`
+	Sha     string    `json:"sha"`
+	Subject string    `json:"subject"`
+}
+
+// Deployment is the last deployment of a repository to an environment.
+type Deployment struct {
+	Build       int       `json:"build"`
+	Deployed    time.Time `json:"deployed"`
+	Environment string    `json:"environment"`
+	Repo        string    `json:"repo"`
+	Sha         string    `json:"sha"`
+}
+
+type file struct {
+	Commits     []*Commit     `json:"commits,omitempty"`
+	Deployments []*Deployment `json:"deployments,omitempty"`
+	Entries     []*Entry      `json:"entries"`
+}
+
+// History is a JSON file of the build history of branches and the deployments
+// of repositories. Use Update to modify the file, so that concurrent writers do
+// not overwrite the entries of each other.
+type History struct {
+	commits     []*Commit
+	deployments []*Deployment
+	entries     []*Entry
+	path        string
+}
+
+// CommitsSince returns the commits of the branch of the repository, which have
+// been built after the commit sinceSha up to and including the commit untilSha,
+// ordered by the time they have been built. If sinceSha has not been recorded,
+// nil is returned, because the changes are unknown.
+func (h *History) CommitsSince(repo string, branch string, sinceSha string, untilSha string) []*Commit {
+	if sinceSha == untilSha {
+		return []*Commit{}
+	}
+
+	start := -1
+	for i, c := range h.commits {
+		if c.Repo == repo && c.Sha == sinceSha {
+			start = i
+		}
+	}
+	if start < 0 {
+		return nil
+	}
+
+	commits := make([]*Commit, 0)
+	for _, c := range h.commits[start+1:] {
+		if c.Repo != repo || c.Branch != branch {
+			continue
+		}
+		commits = append(commits, c)
+		if c.Sha == untilSha {
+			break
+		}
+	}
+
+	return commits
+}
+
+// LastDeployment returns the last deployment of the repository to the
+// environment or nil, if the repository has not been deployed yet.
+func (h *History) LastDeployment(repo string, environment string) *Deployment {
+	for _, d := range h.deployments {
+		if d.Repo == repo && d.Environment == environment {
+			return d
+		}
+	}
+
+	return nil
+}
+
+// Lookup returns the entry of the branch of the repository or nil, if no build
+// has been recorded.
+func (h *History) Lookup(repo string, branch string) *Entry {
+	for _, e := range h.entries {
+		if e.Repo == repo && e.Branch == branch {
+			return e
+		}
+	}
+
+	return nil
+}
+
+// Record records the build of the branch of the repository and returns the
+// updated entry. A failed build increases the number of consecutive failures,
+// a successful build resets it. If the build has already been recorded, the
+// entry is returned unchanged.
+func (h *History) Record(repo string, branch string, build int, failed bool, t time.Time) *Entry {
+	entry := h.Lookup(repo, branch)
+	if entry == nil {
+		entry = &Entry{
+			Branch: branch,
+			Repo:   repo,
+		}
+		h.entries = append(h.entries, entry)
+	}
+
+	if build > 0 && entry.Build == build {
+		return entry
+	}
+
+	entry.Build = build
+	entry.Updated = t
+	if failed {
+		entry.Failures++
+	} else {
+		entry.Failures = 0
+	}
+
+	return entry
+}
+
+// RecordCommit records the built commit. A commit which has already been
+// recorded is not recorded twice.
+func (h *History) RecordCommit(commit *Commit) {
+	n := 0
+	for _, c := range h.commits {
+		if c.Repo != commit.Repo {
+			continue
+		}
+		if c.Sha == commit.Sha {
+			return
+		}
+		n++
+	}
+
+	h.commits = append(h.commits, commit)
+
+	// Drop the oldest commits of the repository
+	for n++; n > maxCommits; n-- {
+		for i, c := range h.commits {
+			if c.Repo == commit.Repo {
+				h.commits = append(h.commits[:i], h.commits[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// RecordDeployment records the deployment and replaces the last deployment of
+// the repository to the environment.
+func (h *History) RecordDeployment(deployment *Deployment) {
+	for i, d := range h.deployments {
+		if d.Repo == deployment.Repo && d.Environment == deployment.Environment {
+			h.deployments[i] = deployment
+			return
+		}
+	}
+
+	h.deployments = append(h.deployments, deployment)
+}
+
+// Save writes the history into its file. The directory of the file is created
+// if it does not exist.
+func (h *History) Save() error {
+	b, err := json.Marshal(&file{
+		Commits:     h.commits,
+		Deployments: h.deployments,
+		Entries:     h.entries,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode history: %w", err)
+	}
+
+	return fileutil.WriteFile(h.path, b)
+}
+
+// Open reads the history of the file. If the file does not exist, an empty
+// history is returned, which creates the file on Save.
+func Open(path string) (*History, error) {
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &History{entries: make([]*Entry, 0), path: path}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	f := new(file)
+	err = json.Unmarshal(b, f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+
+	return &History{
+		commits:     f.Commits,
+		deployments: f.Deployments,
+		entries:     f.Entries,
+		path:        path,
+	}, nil
+}
+
+// Update locks the history file, passes the history to f and saves it
+// afterwards. Concurrent updates of the same file are serialized. If f returns
+// an error, the history is not saved and the error is returned.
+func Update(path string, f func(h *History) error) error {
+	return fileutil.WithLock(path, func() error {
+		h, err := Open(path)
+		if err != nil {
+			return err
+		}
+
+		err = f(h)
+		if err != nil {
+			return err
+		}
+
+		return h.Save()
+	})
+}
@@ -0,0 +1,44 @@
+package history
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUpdateConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	now := time.Now()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- Update(path, func(h *History) error {
+				h.Record("volker.raschek/drone-email", "master", i+1, true, now)
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("failed to update history: %v", err)
+		}
+	}
+
+	h, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to open history: %v", err)
+	}
+
+	entry := h.Lookup("volker.raschek/drone-email", "master")
+	if entry == nil || entry.Failures != 20 {
+		t.Errorf("expected 20 consecutive failures, got %+v", entry)
+	}
+}
@@ -0,0 +1,225 @@
+package loader
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// field is a field of a struct, which is filled by the value of a flag.
+type field struct {
+	defaultValue string
+	env          string
+	index        [][]int
+	kind         reflect.Kind
+	usage        string
+}
+
+// FlagName returns the name of the flag of the environment variable, for
+// example drone-build-number for DRONE_BUILD_NUMBER. Flags are bound to the
+// environment variables by these names.
+func FlagName(env string) string {
+	return strings.ToLower(strings.ReplaceAll(env, "_", "-"))
+}
+
+// AddFlags adds a flag for each field of the struct v and its nested structs,
+// which is tagged with the name of an environment variable via `env`. The tag
+// `default` defines the default value and `usage` the description of the flag.
+// The type of the flag is derived from the type of the field. Supported are
+// bool, int, int64, string and []string fields. The default value of []string
+// fields is a comma separated list.
+func AddFlags(flagSet *pflag.FlagSet, v any) error {
+	fields, err := collect(reflect.TypeOf(v))
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		name := FlagName(f.env)
+		switch f.kind {
+		case reflect.Bool:
+			var defaultValue bool
+			if len(f.defaultValue) > 0 {
+				defaultValue, err = strconv.ParseBool(f.defaultValue)
+				if err != nil {
+					return fmt.Errorf("failed to parse default value of %s: %w", f.env, err)
+				}
+			}
+			flagSet.Bool(name, defaultValue, f.usage)
+		case reflect.Int:
+			var defaultValue int
+			if len(f.defaultValue) > 0 {
+				defaultValue, err = strconv.Atoi(f.defaultValue)
+				if err != nil {
+					return fmt.Errorf("failed to parse default value of %s: %w", f.env, err)
+				}
+			}
+			flagSet.Int(name, defaultValue, f.usage)
+		case reflect.Int64:
+			var defaultValue int64
+			if len(f.defaultValue) > 0 {
+				defaultValue, err = strconv.ParseInt(f.defaultValue, 10, 64)
+				if err != nil {
+					return fmt.Errorf("failed to parse default value of %s: %w", f.env, err)
+				}
+			}
+			flagSet.Int64(name, defaultValue, f.usage)
+		case reflect.String:
+			flagSet.String(name, f.defaultValue, f.usage)
+		case reflect.Slice:
+			defaultValue := []string{}
+			if len(f.defaultValue) > 0 {
+				defaultValue = strings.Split(f.defaultValue, ",")
+			}
+			flagSet.StringArray(name, defaultValue, f.usage)
+		}
+	}
+
+	return nil
+}
+
+// Load fills the tagged fields of the struct v and its nested structs by the
+// values of the flags added by AddFlags. Nil pointers to nested structs are
+// allocated.
+func Load(flagSet *pflag.FlagSet, v any) error {
+	return load(flagSet, v, false)
+}
+
+// LoadChanged fills only the tagged fields, whose flags have been set, for
+// example by the command line, environment variables or the config file. It
+// overrides individual fields of a struct, which has been filled by another
+// source.
+func LoadChanged(flagSet *pflag.FlagSet, v any) error {
+	return load(flagSet, v, true)
+}
+
+func load(flagSet *pflag.FlagSet, v any, changedOnly bool) error {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Pointer || value.IsNil() {
+		return fmt.Errorf("expected a non-nil pointer to a struct, got %T", v)
+	}
+
+	fields, err := collect(value.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		name := FlagName(f.env)
+		if changedOnly && !flagSet.Changed(name) {
+			continue
+		}
+
+		var val any
+		switch f.kind {
+		case reflect.Bool:
+			val, err = flagSet.GetBool(name)
+		case reflect.Int:
+			val, err = flagSet.GetInt(name)
+		case reflect.Int64:
+			val, err = flagSet.GetInt64(name)
+		case reflect.String:
+			val, err = flagSet.GetString(name)
+		case reflect.Slice:
+			val, err = flagSet.GetStringArray(name)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to detect value of %s: %w", name, err)
+		}
+
+		fieldValue(value, f.index).Set(reflect.ValueOf(val))
+	}
+
+	return nil
+}
+
+// collect returns the tagged fields of the struct type t and its nested
+// structs.
+func collect(t reflect.Type) ([]*field, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected a struct, got %s", t)
+	}
+
+	fields := make([]*field, 0)
+	err := collectStruct(t, nil, make(map[reflect.Type]bool), &fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+func collectStruct(t reflect.Type, index [][]int, visited map[reflect.Type]bool, fields *[]*field) error {
+	if visited[t] {
+		return nil
+	}
+	visited[t] = true
+	defer delete(visited, t)
+
+	for _, structField := range reflect.VisibleFields(t) {
+		if !structField.IsExported() || structField.Anonymous {
+			continue
+		}
+
+		fieldIndex := append(append(make([][]int, 0, len(index)+1), index...), structField.Index)
+
+		fieldType := structField.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+
+		env, ok := structField.Tag.Lookup("env")
+		if !ok {
+			if fieldType.Kind() == reflect.Struct {
+				err := collectStruct(fieldType, fieldIndex, visited, fields)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		switch {
+		case structField.Type.Kind() == reflect.Slice && structField.Type.Elem().Kind() == reflect.String:
+		case structField.Type.Kind() == reflect.Bool,
+			structField.Type.Kind() == reflect.Int,
+			structField.Type.Kind() == reflect.Int64,
+			structField.Type.Kind() == reflect.String:
+		default:
+			return fmt.Errorf("unsupported type %s of field %s.%s", structField.Type, t.Name(), structField.Name)
+		}
+
+		*fields = append(*fields, &field{
+			defaultValue: structField.Tag.Get("default"),
+			env:          env,
+			index:        fieldIndex,
+			kind:         structField.Type.Kind(),
+			usage:        structField.Tag.Get("usage"),
+		})
+	}
+
+	return nil
+}
+
+// fieldValue returns the settable value of the field. Nil pointers on the way
+// to the field are allocated.
+func fieldValue(root reflect.Value, index [][]int) reflect.Value {
+	value := root
+	for _, i := range index {
+		for value.Kind() == reflect.Pointer {
+			if value.IsNil() {
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+			value = value.Elem()
+		}
+		value = value.FieldByIndex(i)
+	}
+
+	return value
+}
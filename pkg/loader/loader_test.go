@@ -0,0 +1,164 @@
+package loader
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/mail"
+	"github.com/spf13/pflag"
+)
+
+// testValue returns a distinct value of the field, which differs from its
+// default value.
+func testValue(f *field, i int) any {
+	switch f.kind {
+	case reflect.Bool:
+		return f.defaultValue != "true"
+	case reflect.Int:
+		return i + 1
+	case reflect.Int64:
+		return int64(i + 1000)
+	case reflect.Slice:
+		return []string{strings.ToLower(f.env) + "-1", strings.ToLower(f.env) + "-2"}
+	default:
+		return strings.ToLower(f.env)
+	}
+}
+
+func newTestFlagSet(t *testing.T) (*pflag.FlagSet, []*field) {
+	t.Helper()
+
+	flagSet := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	err := AddFlags(flagSet, &mail.CIVars{})
+	if err != nil {
+		t.Fatalf("failed to add flags: %v", err)
+	}
+
+	fields, err := collect(reflect.TypeOf(&mail.CIVars{}))
+	if err != nil {
+		t.Fatalf("failed to collect fields: %v", err)
+	}
+
+	return flagSet, fields
+}
+
+func TestLoad(t *testing.T) {
+	flagSet, fields := newTestFlagSet(t)
+
+	args := make([]string, 0, len(fields))
+	for i, f := range fields {
+		switch v := testValue(f, i).(type) {
+		case []string:
+			for _, s := range v {
+				args = append(args, fmt.Sprintf("--%s=%s", FlagName(f.env), s))
+			}
+		default:
+			args = append(args, fmt.Sprintf("--%s=%v", FlagName(f.env), v))
+		}
+	}
+
+	err := flagSet.Parse(args)
+	if err != nil {
+		t.Fatalf("failed to parse args: %v", err)
+	}
+
+	ciVars := new(mail.CIVars)
+	err = Load(flagSet, ciVars)
+	if err != nil {
+		t.Fatalf("failed to load flags: %v", err)
+	}
+
+	for i, f := range fields {
+		actual := fieldValue(reflect.ValueOf(ciVars), f.index).Interface()
+		expected := testValue(f, i)
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("expected %v of %s, got %v", expected, f.env, actual)
+		}
+	}
+
+	if ciVars.Repo.FullName != "drone_repo" {
+		t.Errorf("expected repo full name of DRONE_REPO, got %s", ciVars.Repo.FullName)
+	}
+	if ciVars.Repo.Name != "drone_repo_name" {
+		t.Errorf("expected repo name of DRONE_REPO_NAME, got %s", ciVars.Repo.Name)
+	}
+	if ciVars.Commit.Author.Username != "drone_commit_author" {
+		t.Errorf("expected commit author username of DRONE_COMMIT_AUTHOR, got %s", ciVars.Commit.Author.Username)
+	}
+	if ciVars.Job.Status != "drone_job_status" {
+		t.Errorf("expected job status of DRONE_JOB_STATUS, got %s", ciVars.Job.Status)
+	}
+	if ciVars.Job.ExitCode <= 0 || ciVars.Job.Number <= 0 || ciVars.Job.Started < 1000 || ciVars.Job.Finished < 1000 {
+		t.Errorf("expected all job fields to be set, got %+v", ciVars.Job)
+	}
+	if len(ciVars.FailedSteps) != 2 {
+		t.Errorf("expected two failed steps, got %q", ciVars.FailedSteps)
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	flagSet, _ := newTestFlagSet(t)
+
+	ciVars := new(mail.CIVars)
+	err := Load(flagSet, ciVars)
+	if err != nil {
+		t.Fatalf("failed to load flags: %v", err)
+	}
+
+	if ciVars.Build.Event != "push" {
+		t.Errorf("expected default build event push, got %s", ciVars.Build.Event)
+	}
+	if !ciVars.Repo.Private {
+		t.Error("expected default private repository")
+	}
+	if ciVars.Commit.Ref != "refs/heads/master" {
+		t.Errorf("expected default commit ref refs/heads/master, got %s", ciVars.Commit.Ref)
+	}
+}
+
+func TestLoadChanged(t *testing.T) {
+	flagSet, _ := newTestFlagSet(t)
+	err := flagSet.Parse([]string{"--drone-build-number=7", "--drone-repo-name=drone-email"})
+	if err != nil {
+		t.Fatalf("failed to parse args: %v", err)
+	}
+
+	ciVars := mail.NewCIVars()
+	ciVars.Build.Status = "failure"
+	ciVars.Repo.FullName = "volker.raschek/drone-email-docker"
+	ciVars.Repo.Name = "drone-email-docker"
+	ciVars.Semver = nil
+
+	err = LoadChanged(flagSet, ciVars)
+	if err != nil {
+		t.Fatalf("failed to load changed flags: %v", err)
+	}
+
+	if ciVars.Build.Number != 7 {
+		t.Errorf("expected build number 7, got %d", ciVars.Build.Number)
+	}
+	if ciVars.Repo.Name != "drone-email" {
+		t.Errorf("expected repo name drone-email, got %s", ciVars.Repo.Name)
+	}
+	if ciVars.Build.Status != "failure" {
+		t.Errorf("expected build status to be left alone, got %s", ciVars.Build.Status)
+	}
+	if ciVars.Build.Event != "" {
+		t.Errorf("expected build event to be left alone instead of the default, got %s", ciVars.Build.Event)
+	}
+	if ciVars.Repo.FullName != "volker.raschek/drone-email-docker" {
+		t.Errorf("expected repo full name to be left alone, got %s", ciVars.Repo.FullName)
+	}
+	if ciVars.Semver != nil {
+		t.Errorf("expected semver not to be allocated, got %+v", ciVars.Semver)
+	}
+}
+
+func TestLoadInvalidValue(t *testing.T) {
+	err := Load(pflag.NewFlagSet("test", pflag.ContinueOnError), mail.CIVars{})
+	if err == nil {
+		t.Error("expected an error of a value, which is not a pointer")
+	}
+}
@@ -0,0 +1,34 @@
+package mail
+
+import (
+	"fmt"
+	"maps"
+	"os"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// readData reads the data files of the templates and merges their documents
+// into a single map. The top-level keys of later files override the keys of
+// earlier files.
+func (p *Plugin) readData() (map[string]any, error) {
+	data := make(map[string]any)
+	for _, dataFile := range p.templateSettings.DataFiles {
+		// #nosec G304
+		b, err := os.ReadFile(dataFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read data file %s: %w", dataFile, err)
+		}
+
+		// JSON documents are valid YAML documents.
+		document := make(map[string]any)
+		err = yaml.Unmarshal(b, &document)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode data file %s: %w", dataFile, err)
+		}
+
+		maps.Copy(data, document)
+	}
+
+	return data, nil
+}
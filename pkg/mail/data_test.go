@@ -0,0 +1,59 @@
+package mail
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/domain"
+)
+
+func TestReadData(t *testing.T) {
+	dir := t.TempDir()
+
+	jsonFile := filepath.Join(dir, "data.json")
+	err := os.WriteFile(jsonFile, []byte(`{"owner": "max", "image": {"name": "drone-email", "tag": "1.0.0"}}`), 0o600)
+	if err != nil {
+		t.Fatalf("failed to write %s: %v", jsonFile, err)
+	}
+
+	yamlFile := filepath.Join(dir, "data.yaml")
+	err = os.WriteFile(yamlFile, []byte("image:\n  tag: 1.2.3\nreplicas: 3\n"), 0o600)
+	if err != nil {
+		t.Fatalf("failed to write %s: %v", yamlFile, err)
+	}
+
+	p := NewPlugin(&Settings{
+		Template: &domain.TemplateSettings{DataFiles: []string{jsonFile, yamlFile}},
+	})
+
+	data, err := p.readData()
+	if err != nil {
+		t.Fatalf("failed to read data: %v", err)
+	}
+
+	// Top-level keys of later files replace the keys of earlier files as a whole.
+	expected := map[string]any{
+		"image":    map[string]any{"tag": "1.2.3"},
+		"owner":    "max",
+		"replicas": 3,
+	}
+	if !reflect.DeepEqual(data, expected) {
+		t.Errorf("expected %v, got %v", expected, data)
+	}
+}
+
+func TestReadDataMissingFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "missing.yaml")
+
+	p := NewPlugin(&Settings{
+		Template: &domain.TemplateSettings{DataFiles: []string{name}},
+	})
+
+	_, err := p.readData()
+	if err == nil || !strings.Contains(err.Error(), name) {
+		t.Errorf("expected an error containing the name of the data file, got %v", err)
+	}
+}
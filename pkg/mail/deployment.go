@@ -0,0 +1,114 @@
+package mail
+
+import (
+	"fmt"
+	"time"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/domain"
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/history"
+
+	_ "embed"
+)
+
+//go:embed assets/deployment.txt
+var deploymentTemplate string
+
+// deployment contains the values of a deployment, which are passed to the
+// deployment template.
+type deployment struct {
+	// Changes are the commits built since the last deployment to the
+	// environment. Nil, if the changes are unknown.
+	Changes []*history.Commit
+
+	Environment string
+
+	// Previous is the last deployment to the environment. Nil, if unknown.
+	Previous *history.Deployment
+
+	Rollback bool
+
+	recipients []string
+}
+
+// isDeployment returns true if the build deploys, promotes or rolls back to an
+// environment.
+func isDeployment(ciVars *CIVars) bool {
+	if len(ciVars.DeployTo) > 0 {
+		return true
+	}
+
+	return ciVars.Build != nil && (ciVars.Build.IsEvent(domain.EventPromote) || ciVars.Build.IsEvent(domain.EventRollback))
+}
+
+// deploy returns the deployment of the build or nil, if the build is not a
+// deployment. If a history file is defined, the built commit and successful
+// deployments are recorded. In a dry run the history is not saved.
+func (p *Plugin) deploy(ciVars *CIVars, now time.Time) (*deployment, error) {
+	var d *deployment
+	if isDeployment(ciVars) {
+		d = &deployment{
+			Environment: ciVars.DeployTo,
+			Rollback:    ciVars.Build != nil && ciVars.Build.IsEvent(domain.EventRollback),
+		}
+
+		for _, deploymentRecipients := range p.deploymentSettings.Recipients {
+			ok, err := matchGlob(deploymentRecipients.Environment, d.Environment)
+			if err != nil {
+				return nil, fmt.Errorf("failed to match environment %s: %w", deploymentRecipients.Environment, err)
+			}
+			if ok {
+				d.recipients = append(d.recipients, deploymentRecipients.Recipients...)
+			}
+		}
+	}
+
+	if len(p.deploymentSettings.HistoryFile) <= 0 || ciVars.Commit == nil || ciVars.Repo == nil {
+		return d, nil
+	}
+
+	err := p.updateHistory(p.deploymentSettings.HistoryFile, func(h *history.History) error {
+		if d != nil {
+			d.Previous = h.LastDeployment(ciVars.Repo.FullName, d.Environment)
+			if d.Previous != nil && !d.Rollback {
+				d.Changes = h.CommitsSince(ciVars.Repo.FullName, ciVars.Commit.Branch, d.Previous.Sha, ciVars.Commit.Sha)
+			}
+		}
+
+		var author string
+		if ciVars.Commit.Author != nil {
+			author = ciVars.Commit.Author.Name
+		}
+
+		h.RecordCommit(&history.Commit{
+			Author:  author,
+			Branch:  ciVars.Commit.Branch,
+			Built:   now,
+			Link:    ciVars.Commit.Link,
+			Repo:    ciVars.Repo.FullName,
+			Sha:     ciVars.Commit.Sha,
+			Subject: ciVars.Commit.ParsedMessage().Subject,
+		})
+
+		if d != nil && !ciVars.Transition().IsFailure() {
+			var buildNumber int
+			if ciVars.Build != nil {
+				buildNumber = ciVars.Build.Number
+			}
+
+			h.RecordDeployment(&history.Deployment{
+				Build:       buildNumber,
+				Deployed:    now,
+				Environment: d.Environment,
+				Repo:        ciVars.Repo.FullName,
+				Sha:         ciVars.Commit.Sha,
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update history: %w", err)
+	}
+
+	return d, nil
+}
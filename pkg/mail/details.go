@@ -0,0 +1,88 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/domain"
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/droneapi"
+)
+
+// details contains the stages and steps of the build fetched from the drone
+// API, which are passed to the templates.
+type details struct {
+	// Failed are the failed steps of all stages.
+	Failed []*failedStep
+
+	Stages []*droneapi.Stage
+}
+
+// failedStep is a failed step including the tail of its log.
+type failedStep struct {
+	Link  string
+	Log   []string
+	Stage *droneapi.Stage
+	Step  *droneapi.Step
+}
+
+// fetchDetails returns the stages and steps of the build and the log tails of
+// the failed steps. If the drone API is not enabled, nil is returned.
+func (p *Plugin) fetchDetails(ctx context.Context, ciVars *CIVars) (*details, error) {
+	if !p.droneAPISettings.Enabled() || ciVars.Build == nil || ciVars.Repo == nil {
+		return nil, nil
+	}
+
+	server := p.droneAPISettings.Server
+	if len(server) <= 0 && ciVars.System != nil {
+		server = ciVars.System.Link()
+	}
+	if len(server) <= 0 {
+		return nil, fmt.Errorf("drone server is unknown")
+	}
+
+	owner, name, ok := strings.Cut(ciVars.Repo.FullName, "/")
+	if !ok {
+		return nil, fmt.Errorf("failed to split repository %s into owner and name", ciVars.Repo.FullName)
+	}
+
+	client := droneapi.New(server, p.droneAPISettings.Token, &http.Client{Timeout: 30 * time.Second})
+
+	build, err := client.Build(ctx, owner, name, ciVars.Build.Number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch build: %w", err)
+	}
+
+	buildLink := ciVars.Build.Link
+	if len(buildLink) <= 0 {
+		buildLink = fmt.Sprintf("%s/%s/%d", strings.TrimSuffix(server, "/"), ciVars.Repo.FullName, ciVars.Build.Number)
+	}
+
+	d := &details{
+		Failed: make([]*failedStep, 0),
+		Stages: build.Stages,
+	}
+	for _, stage := range build.Stages {
+		for _, step := range stage.Steps {
+			if step.Status != domain.StatusFailure && step.Status != domain.StatusError {
+				continue
+			}
+
+			lines, err := client.Logs(ctx, owner, name, ciVars.Build.Number, stage.Number, step.Number)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch logs of step %s: %w", step.Name, err)
+			}
+
+			d.Failed = append(d.Failed, &failedStep{
+				Link:  fmt.Sprintf("%s/%d/%d", strings.TrimSuffix(buildLink, "/"), stage.Number, step.Number),
+				Log:   droneapi.Tail(lines, p.droneAPISettings.LogLines),
+				Stage: stage,
+				Step:  step,
+			})
+		}
+	}
+
+	return d, nil
+}
@@ -0,0 +1,102 @@
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/domain"
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/droneapi"
+)
+
+func TestFetchDetails(t *testing.T) {
+	var mu sync.Mutex
+	logRequests := make([]string, 0)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/repos/volker.raschek/drone-email/builds/42", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(&droneapi.Build{
+			Number: 42,
+			Stages: []*droneapi.Stage{
+				{Name: "build", Number: 1, Steps: []*droneapi.Step{
+					{Name: "clone", Number: 1, Status: domain.StatusSuccess},
+					{Name: "test", Number: 2, Status: domain.StatusFailure},
+					{Name: "notify", Number: 3, Status: "skipped"},
+				}},
+				{Name: "deploy", Number: 2, Steps: []*droneapi.Step{
+					{Name: "push", Number: 1, Status: domain.StatusError},
+					{Name: "cleanup", Number: 2, Status: "killed"},
+				}},
+			},
+		})
+	})
+	mux.HandleFunc("GET /api/repos/volker.raschek/drone-email/builds/42/logs/{stage}/{step}", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		logRequests = append(logRequests, fmt.Sprintf("%s/%s", r.PathValue("stage"), r.PathValue("step")))
+		mu.Unlock()
+
+		_ = json.NewEncoder(w).Encode([]*droneapi.Line{
+			{Out: "first\n"},
+			{Out: "second\nthird\n"},
+		})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	p := NewPlugin(&Settings{
+		DroneAPI: &domain.DroneAPISettings{
+			LogLines: 2,
+			Server:   server.URL,
+			Token:    "secret",
+		},
+	})
+
+	ciVars := NewCIVars()
+	ciVars.Build.Link = "https://drone.example.com/volker.raschek/drone-email/42"
+	ciVars.Build.Number = 42
+	ciVars.Repo.FullName = "volker.raschek/drone-email"
+
+	det, err := p.fetchDetails(context.Background(), ciVars)
+	if err != nil {
+		t.Fatalf("failed to fetch details: %v", err)
+	}
+
+	if expected := []string{"1/2", "2/1"}; !slices.Equal(logRequests, expected) {
+		t.Errorf("expected logs of failed steps %q, got %q", expected, logRequests)
+	}
+
+	if len(det.Stages) != 2 {
+		t.Errorf("expected 2 stages, got %d", len(det.Stages))
+	}
+
+	if len(det.Failed) != 2 {
+		t.Fatalf("expected 2 failed steps, got %d", len(det.Failed))
+	}
+	if det.Failed[0].Step.Name != "test" || det.Failed[1].Step.Name != "push" {
+		t.Errorf("expected failed steps test and push, got %s and %s", det.Failed[0].Step.Name, det.Failed[1].Step.Name)
+	}
+	if expected := "https://drone.example.com/volker.raschek/drone-email/42/2/1"; det.Failed[1].Link != expected {
+		t.Errorf("expected link %s, got %s", expected, det.Failed[1].Link)
+	}
+	if expected := []string{"second", "third"}; !slices.Equal(det.Failed[0].Log, expected) {
+		t.Errorf("expected log %q, got %q", expected, det.Failed[0].Log)
+	}
+}
+
+func TestFetchDetailsDisabled(t *testing.T) {
+	p := NewPlugin(&Settings{})
+
+	det, err := p.fetchDetails(context.Background(), NewCIVars())
+	if err != nil {
+		t.Fatalf("failed to fetch details: %v", err)
+	}
+	if det != nil {
+		t.Errorf("expected no details without token, got %+v", det)
+	}
+}
@@ -0,0 +1,241 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	netmail "net/mail"
+	"slices"
+	"sort"
+	"text/template"
+	"time"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/digest"
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/directory"
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/domain"
+
+	_ "embed"
+)
+
+//go:embed assets/digest.txt
+var digestTemplate string
+
+type digestVars struct {
+	Builds       []*CIVars
+	Data         map[string]any
+	Failed       int
+	Groups       []*digestGroup
+	Passed       int
+	Recipient    *netmail.Address
+	SMTPSettings *domain.SMTPSettings
+	Vars         map[string]any
+}
+
+func (d *digestVars) TimeNowFormat(layout string) string {
+	return time.Now().Format(layout)
+}
+
+// digestGroup contains the builds of a branch of a repository.
+type digestGroup struct {
+	Branch string
+	Builds []*CIVars
+	Failed int
+	Passed int
+	Repo   string
+}
+
+// queueDigest appends the build to the digest spool. In a dry run the build is
+// not queued.
+func (p *Plugin) queueDigest(ciVars *CIVars, now time.Time) error {
+	if len(p.digestSettings.Spool) <= 0 {
+		return fmt.Errorf("notify mode %s requires a digest spool", p.digestSettings.Mode)
+	}
+
+	if p.dryRunSettings.Enabled {
+		return nil
+	}
+
+	b, err := json.Marshal(ciVars)
+	if err != nil {
+		return fmt.Errorf("failed to encode build: %w", err)
+	}
+
+	err = digest.Append(p.digestSettings.Spool, &digest.Entry{Data: b, Queued: now})
+	if err != nil {
+		return fmt.Errorf("failed to queue build for digest: %w", err)
+	}
+
+	return nil
+}
+
+// SendDigest sends a summary of all builds of the digest spool to the
+// recipients and removes the builds from the spool afterwards, except in a dry
+// run. It returns the number of summarized builds. If no builds are queued or
+// no recipients are left after applying the recipient policy and opt-outs, a
+// SkipError is returned and the builds are kept in the spool.
+func (p *Plugin) SendDigest(ctx context.Context, recipients []string) (int, error) {
+	entries, err := digest.Read(p.digestSettings.Spool)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read digest spool: %w", err)
+	}
+
+	if len(entries) <= 0 {
+		return 0, &SkipError{Reason: "no builds queued for the digest"}
+	}
+
+	builds := make([]*CIVars, 0, len(entries))
+	for _, entry := range entries {
+		ciVars := new(CIVars)
+		err = json.Unmarshal(entry.Data, ciVars)
+		if err != nil {
+			return 0, fmt.Errorf("failed to decode build: %w", err)
+		}
+		builds = append(builds, ciVars)
+	}
+
+	var dir *directory.Directory
+	if len(p.recipientSettings.DirectoryFile) > 0 {
+		dir, err = directory.ReadFile(p.recipientSettings.DirectoryFile)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read team directory: %w", err)
+		}
+	}
+
+	rcpts := newRecipientSet(dir)
+	for _, recipient := range recipients {
+		err = rcpts.AddString(recipient)
+		if err != nil {
+			return 0, fmt.Errorf("failed to resolve recipients: %w", err)
+		}
+	}
+
+	// The digest summarizes builds of several repositories. The allow list of
+	// private repositories is applied, if any of them is private. Recipients
+	// are only dropped, if they opted out of all mails, because a digest has no
+	// single build status.
+	repo := &domain.Repo{
+		Private: slices.ContainsFunc(builds, func(build *CIVars) bool {
+			return build.Repo != nil && build.Repo.Private
+		}),
+	}
+
+	rcpts, err = p.applyRecipientPolicy(rcpts, repo)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve recipients: %w", err)
+	}
+
+	rcpts, err = p.applyOptOuts(rcpts, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve recipients: %w", err)
+	}
+
+	if len(rcpts.Addresses()) <= 0 {
+		return 0, &SkipError{Reason: fmt.Sprintf("no recipients of the digest left, %d builds kept in the spool", len(builds))}
+	}
+
+	messages, err := p.renderDigest(rcpts, builds)
+	if err != nil {
+		return 0, &RenderError{Err: err}
+	}
+
+	err = p.send(ctx, messages)
+	if err != nil {
+		return 0, err
+	}
+
+	if p.dryRunSettings.Enabled {
+		return len(builds), nil
+	}
+
+	err = digest.Remove(p.digestSettings.Spool, len(entries))
+	if err != nil {
+		return len(builds), fmt.Errorf("failed to clear digest spool: %w", err)
+	}
+
+	return len(builds), nil
+}
+
+// renderDigest renders the digest template of the builds for each recipient.
+func (p *Plugin) renderDigest(rcpts *recipientSet, builds []*CIVars) ([]*Message, error) {
+	tpl, err := template.New("digest").Parse(digestTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse digest template: %w", err)
+	}
+
+	data, err := p.readData()
+	if err != nil {
+		return nil, err
+	}
+
+	groups, passed, failed := groupDigestBuilds(builds)
+
+	messages := make([]*Message, 0, len(rcpts.Addresses()))
+	for _, recipient := range rcpts.Addresses() {
+		buffer := new(bytes.Buffer)
+		err = tpl.Execute(buffer, &digestVars{
+			Builds:       builds,
+			Data:         data,
+			Failed:       failed,
+			Groups:       groups,
+			Passed:       passed,
+			Recipient:    recipient,
+			SMTPSettings: p.smtpSettings,
+			Vars:         p.templateSettings.Vars,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate digest template: %w", err)
+		}
+
+		messages = append(messages, &Message{
+			Data:      buffer.Bytes(),
+			Recipient: recipient.Address,
+		})
+	}
+
+	return messages, nil
+}
+
+// groupDigestBuilds groups the builds by repository and branch, ordered by
+// their names. Furthermore the total number of passed and failed builds is
+// returned.
+func groupDigestBuilds(builds []*CIVars) ([]*digestGroup, int, int) {
+	var passed, failed int
+	groups := make([]*digestGroup, 0)
+	groupsByKey := make(map[[2]string]*digestGroup)
+	for _, build := range builds {
+		var branch, fullName string
+		if build.Commit != nil {
+			branch = build.Commit.Branch
+		}
+		if build.Repo != nil {
+			fullName = build.Repo.FullName
+		}
+
+		key := [2]string{fullName, branch}
+		group, present := groupsByKey[key]
+		if !present {
+			group = &digestGroup{Branch: branch, Repo: fullName}
+			groupsByKey[key] = group
+			groups = append(groups, group)
+		}
+
+		group.Builds = append(group.Builds, build)
+		if build.Transition().IsFailure() {
+			group.Failed++
+			failed++
+		} else {
+			group.Passed++
+			passed++
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Repo != groups[j].Repo {
+			return groups[i].Repo < groups[j].Repo
+		}
+		return groups[i].Branch < groups[j].Branch
+	})
+
+	return groups, passed, failed
+}
@@ -0,0 +1,47 @@
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/digest"
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/domain"
+)
+
+func TestSendDigestNoRecipients(t *testing.T) {
+	spool := filepath.Join(t.TempDir(), "digest.jsonl")
+
+	b, err := json.Marshal(NewCIVars())
+	if err != nil {
+		t.Fatalf("failed to encode build: %v", err)
+	}
+	err = digest.Append(spool, &digest.Entry{Data: b, Queued: time.Now()})
+	if err != nil {
+		t.Fatalf("failed to queue build: %v", err)
+	}
+
+	p := NewPlugin(&Settings{
+		Digest: &domain.DigestSettings{Mode: domain.NotifyModeDigest, Spool: spool},
+		Recipient: &domain.RecipientSettings{
+			DenyList: []string{"example.com"},
+		},
+	})
+
+	_, err = p.SendDigest(context.Background(), []string{"team@example.com"})
+	skipErr := new(SkipError)
+	if !errors.As(err, &skipErr) {
+		t.Fatalf("expected a skip error without recipients, got %v", err)
+	}
+
+	entries, err := digest.Read(spool)
+	if err != nil {
+		t.Fatalf("failed to read digest spool: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected the build to be kept in the spool, got %d builds", len(entries))
+	}
+}
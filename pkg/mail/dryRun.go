@@ -0,0 +1,60 @@
+package mail
+
+import (
+	"fmt"
+)
+
+// dryRunMail prints the message instead of sending it. If configured, the SMTP
+// session is verified up to the RCPT command and reset afterwards.
+func (p *Plugin) dryRunMail(message *Message) error {
+	if p.dryRunSettings.SMTP {
+		err := p.verifyMail(message.Recipient)
+		if err != nil {
+			return &DeliveryError{Err: fmt.Errorf("failed to verify mail: %w", err)}
+		}
+	}
+
+	return p.printMessage(fmt.Sprintf("E-Mail to %s", message.Recipient), message)
+}
+
+// printMessage prints the title and the message on the output of the plugin.
+func (p *Plugin) printMessage(title string, message *Message) error {
+	_, err := fmt.Fprintf(p.output, "--- %s ---\n%s\n", title, message.Data)
+	if err != nil {
+		return fmt.Errorf("failed to print mail: %w", err)
+	}
+
+	return nil
+}
+
+// verifyMail sends all SMTP commands to deliver a mail to the recipient except
+// DATA. The transaction is reset afterwards.
+func (p *Plugin) verifyMail(recipient string) error {
+	smtpClient, err := p.dial()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = smtpClient.Close() }()
+
+	err = smtpClient.Mail(p.smtpSettings.FromAddress)
+	if err != nil {
+		return fmt.Errorf("failed to sent mail command: %w", err)
+	}
+
+	err = smtpClient.Rcpt(recipient)
+	if err != nil {
+		return fmt.Errorf("failed to sent rcpt command for %s: %w", recipient, err)
+	}
+
+	err = smtpClient.Reset()
+	if err != nil {
+		return fmt.Errorf("failed to send rset command: %w", err)
+	}
+
+	err = smtpClient.Quit()
+	if err != nil {
+		return fmt.Errorf("failed to send quit command: %w", err)
+	}
+
+	return nil
+}
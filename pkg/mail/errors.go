@@ -0,0 +1,29 @@
+package mail
+
+// DeliveryError is returned, when a rendered mail could not be delivered to the
+// SMTP server. The error message is the message of the wrapped error.
+type DeliveryError struct {
+	Err error
+}
+
+func (e *DeliveryError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// RenderError is returned, when a template could not be rendered. The error
+// message is the message of the wrapped error.
+type RenderError struct {
+	Err error
+}
+
+func (e *RenderError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RenderError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,88 @@
+package mail
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/domain"
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/history"
+
+	_ "embed"
+)
+
+//go:embed assets/escalation.txt
+var escalationTemplate string
+
+// escalation contains the values of an escalated failure, which are passed to
+// the escalation template.
+type escalation struct {
+	// Failures is the number of consecutive failed builds of the branch.
+	Failures int
+
+	// Level is the number of the highest reached escalation level, starting at 1.
+	Level int
+
+	// Threshold is the threshold of the highest reached escalation level.
+	Threshold int
+
+	recipients []string
+}
+
+// escalate records the build in the history and returns the escalation, if the
+// threshold of at least one escalation level has been reached. Otherwise nil is
+// returned. In a dry run the history is not saved.
+func (p *Plugin) escalate(ciVars *CIVars, now time.Time) (*escalation, error) {
+	if !p.escalationSettings.Enabled() {
+		return nil, nil
+	}
+
+	levels := make([]*domain.EscalationLevel, len(p.escalationSettings.Levels))
+	copy(levels, p.escalationSettings.Levels)
+	for _, level := range levels {
+		if level.Threshold <= 0 {
+			return nil, fmt.Errorf("threshold %d of escalation level must be greater than 0", level.Threshold)
+		}
+	}
+	sort.SliceStable(levels, func(i, j int) bool {
+		return levels[i].Threshold < levels[j].Threshold
+	})
+
+	var branch, fullName string
+	var buildNumber int
+	failed := ciVars.Transition().IsFailure()
+	if ciVars.Build != nil {
+		buildNumber = ciVars.Build.Number
+	}
+	if ciVars.Commit != nil {
+		branch = ciVars.Commit.Branch
+	}
+	if ciVars.Repo != nil {
+		fullName = ciVars.Repo.FullName
+	}
+
+	var entry *history.Entry
+	err := p.updateHistory(p.escalationSettings.HistoryFile, func(h *history.History) error {
+		entry = h.Record(fullName, branch, buildNumber, failed, now)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update history: %w", err)
+	}
+
+	var e *escalation
+	for i, level := range levels {
+		if entry.Failures < level.Threshold {
+			break
+		}
+
+		if e == nil {
+			e = &escalation{Failures: entry.Failures}
+		}
+		e.Level = i + 1
+		e.Threshold = level.Threshold
+		e.recipients = append(e.recipients, level.Recipients...)
+	}
+
+	return e, nil
+}
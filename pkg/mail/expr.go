@@ -0,0 +1,34 @@
+package mail
+
+import (
+	"fmt"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/expr"
+)
+
+// exprVars are the variables accessible by expressions. Besides the fields of
+// the CIVars the transition of the build status is available.
+type exprVars struct {
+	CIVars
+	Transition string
+}
+
+var exprEnv = expr.NewEnv(&exprVars{})
+
+// evalExpr compiles and evaluates the expression against the CIVars.
+func evalExpr(source string, ciVars *CIVars) (bool, error) {
+	program, err := expr.Compile(source, exprEnv)
+	if err != nil {
+		return false, fmt.Errorf("failed to compile expression %s: %w", source, err)
+	}
+
+	ok, err := program.Eval(&exprVars{
+		CIVars:     *ciVars,
+		Transition: string(ciVars.Transition()),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate expression %s: %w", source, err)
+	}
+
+	return ok, nil
+}
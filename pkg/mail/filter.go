@@ -0,0 +1,246 @@
+package mail
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/domain"
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/expr"
+)
+
+// SkipError is returned by Exec, when no mail has been sent because the
+// conditions to send mails are not met.
+type SkipError struct {
+	Reason string
+}
+
+func (e *SkipError) Error() string {
+	return fmt.Sprintf("skipped: %s", e.Reason)
+}
+
+// matchFunc returns true if the value matches the pattern.
+type matchFunc func(pattern string, value string) (bool, error)
+
+func matchEqual(pattern string, value string) (bool, error) {
+	return pattern == value, nil
+}
+
+func matchGlob(pattern string, value string) (bool, error) {
+	ok, err := path.Match(pattern, value)
+	if err != nil {
+		return false, fmt.Errorf("failed to match glob pattern %s: %w", pattern, err)
+	}
+	return ok, nil
+}
+
+func matchRegexp(pattern string, value string) (bool, error) {
+	ok, err := regexp.MatchString(pattern, value)
+	if err != nil {
+		return false, fmt.Errorf("failed to match regular expression %s: %w", pattern, err)
+	}
+	return ok, nil
+}
+
+// matchAny returns true if the value matches any of the patterns.
+func matchAny(patterns []string, value string, match matchFunc) (bool, error) {
+	for _, pattern := range patterns {
+		ok, err := match(pattern, value)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// checkFilters returns a SkipError if the conditions to send mails are not met.
+func (p *Plugin) checkFilters(ciVars *CIVars) error {
+	err := p.checkCondition(ciVars)
+	if err != nil {
+		return err
+	}
+
+	var branch, event, fullName, status string
+	if ciVars.Build != nil {
+		event = ciVars.Build.Event
+		status = ciVars.Build.Status
+	}
+	if ciVars.Commit != nil {
+		branch = ciVars.Commit.Branch
+	}
+	if ciVars.Repo != nil {
+		fullName = ciVars.Repo.FullName
+	}
+
+	for _, f := range []struct {
+		name   string
+		filter *domain.Filter
+		value  string
+		match  matchFunc
+	}{
+		{name: "event", filter: p.filterSettings.Events, value: event, match: matchEqual},
+		{name: "status", filter: p.filterSettings.Statuses, value: status, match: matchEqual},
+		{name: "deploy target", filter: p.filterSettings.DeployTo, value: ciVars.DeployTo, match: matchEqual},
+		{name: "repository", filter: p.filterSettings.Repos, value: fullName, match: matchGlob},
+		{name: "branch", filter: exclusions(p.filterSettings.Branches), value: branch, match: matchGlob},
+		{name: "tag", filter: exclusions(p.filterSettings.Tags), value: ciVars.Tag, match: matchRegexp},
+	} {
+		if f.filter == nil {
+			continue
+		}
+
+		if len(f.filter.Include) > 0 {
+			ok, err := matchAny(f.filter.Include, f.value, f.match)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return &SkipError{Reason: fmt.Sprintf("%s %s is not included", f.name, f.value)}
+			}
+		}
+
+		// Empty values, for example the tag of a build which was not triggered by a
+		// tag, are never excluded.
+		if len(f.value) <= 0 {
+			continue
+		}
+
+		ok, err := matchAny(f.filter.Exclude, f.value, f.match)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return &SkipError{Reason: fmt.Sprintf("%s %s is excluded", f.name, f.value)}
+		}
+	}
+
+	err = p.checkRefs(branch, ciVars.Tag)
+	if err != nil {
+		return err
+	}
+
+	if len(p.filterSettings.When) > 0 {
+		ok, err := evalExpr(p.filterSettings.When, ciVars)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return &SkipError{Reason: fmt.Sprintf("expression %s evaluated to false", p.filterSettings.When)}
+		}
+	}
+
+	return nil
+}
+
+// validateFilters returns an error if a regular expression or expression of the
+// filters or conditional recipients is invalid, so that invalid settings are
+// reported independent of the build.
+func (p *Plugin) validateFilters() error {
+	if p.filterSettings.Tags != nil {
+		for _, pattern := range append(p.filterSettings.Tags.Include, p.filterSettings.Tags.Exclude...) {
+			_, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("failed to compile regular expression %s: %w", pattern, err)
+			}
+		}
+	}
+
+	sources := make([]string, 0, len(p.recipientSettings.Conditional)+1)
+	if len(p.filterSettings.When) > 0 {
+		sources = append(sources, p.filterSettings.When)
+	}
+	for _, conditional := range p.recipientSettings.Conditional {
+		sources = append(sources, conditional.When)
+	}
+
+	for _, source := range sources {
+		_, err := expr.Compile(source, exprEnv)
+		if err != nil {
+			return fmt.Errorf("failed to compile expression %s: %w", source, err)
+		}
+	}
+
+	return nil
+}
+
+// checkCondition returns a SkipError if the transition of the build status does
+// not meet the condition.
+func (p *Plugin) checkCondition(ciVars *CIVars) error {
+	transition := ciVars.Transition()
+
+	var send bool
+	switch p.filterSettings.Condition {
+	case domain.ConditionAlways, "":
+		send = true
+	case domain.ConditionChange:
+		send = transition == domain.TransitionBroken || transition == domain.TransitionFixed
+	case domain.ConditionFailure:
+		send = transition.IsFailure()
+	case domain.ConditionFailureAndFixed:
+		send = transition.IsFailure() || transition == domain.TransitionFixed
+	case domain.ConditionFixed:
+		send = transition == domain.TransitionFixed
+	default:
+		return fmt.Errorf("unsupported condition %s", p.filterSettings.Condition)
+	}
+
+	if !send {
+		return &SkipError{
+			Reason: fmt.Sprintf("condition %s not met by build status transition %s", p.filterSettings.Condition, transition),
+		}
+	}
+
+	return nil
+}
+
+// checkRefs returns a SkipError if included branches or tags are defined and
+// neither the branch nor the tag is included. Branches and tags are
+// alternatives, so that for example builds of release branches and of any tag
+// can be included at once.
+func (p *Plugin) checkRefs(branch string, tag string) error {
+	var branches, tags []string
+	if p.filterSettings.Branches != nil {
+		branches = p.filterSettings.Branches.Include
+	}
+	if p.filterSettings.Tags != nil {
+		tags = p.filterSettings.Tags.Include
+	}
+
+	if len(branches) <= 0 && len(tags) <= 0 {
+		return nil
+	}
+
+	ok, err := matchAny(branches, branch, matchGlob)
+	if err != nil || ok {
+		return err
+	}
+
+	if len(tag) > 0 {
+		ok, err = matchAny(tags, tag, matchRegexp)
+		if err != nil || ok {
+			return err
+		}
+	}
+
+	if len(tag) > 0 {
+		return &SkipError{Reason: fmt.Sprintf("neither branch %s nor tag %s is included", branch, tag)}
+	}
+
+	return &SkipError{Reason: fmt.Sprintf("branch %s is not included", branch)}
+}
+
+// exclusions returns a filter with the exclusions of the passed filter only. The
+// inclusions of branches and tags are checked together by checkRefs.
+func exclusions(f *domain.Filter) *domain.Filter {
+	if f == nil {
+		return nil
+	}
+
+	return &domain.Filter{
+		Exclude: f.Exclude,
+	}
+}
@@ -0,0 +1,21 @@
+package mail
+
+import (
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/history"
+)
+
+// updateHistory passes the history of the file to f and saves it afterwards,
+// while the file is locked. In a dry run the history is only read and not
+// saved.
+func (p *Plugin) updateHistory(path string, f func(h *history.History) error) error {
+	if !p.dryRunSettings.Enabled {
+		return history.Update(path, f)
+	}
+
+	h, err := history.Open(path)
+	if err != nil {
+		return err
+	}
+
+	return f(h)
+}
@@ -7,11 +7,19 @@ import (
 	"fmt"
 	"io"
 	"net"
+	netmail "net/mail"
 	"net/smtp"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
 	"text/template"
 	"time"
 
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/directory"
 	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/domain"
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/optout"
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/spool"
 
 	_ "embed"
 )
@@ -30,22 +38,104 @@ const (
 var mailTemplate string
 
 type CIVars struct {
-	Build       *domain.Build
-	Commit      *domain.Commit
-	DeployTo    string
-	Job         *domain.Job
-	Prev        *domain.Prev
-	PullRequest int
-	Remote      *domain.Remote
-	Repo        *domain.Repo
-	Tag         string
-	Yaml        *domain.Yaml
+	Build        *domain.Build
+	Commit       *domain.Commit
+	DeployTo     string   `env:"DRONE_DEPLOY_TO" usage:"Deploy target"`
+	FailedStages []string `env:"DRONE_FAILED_STAGES" usage:"Names of the failed stages"`
+	FailedSteps  []string `env:"DRONE_FAILED_STEPS" usage:"Names of the failed steps"`
+	Job          *domain.Job
+	Prev         *domain.Prev
+	PullRequest  int `env:"DRONE_PULL_REQUEST" usage:"Number of pull-request"`
+	// PullRequestAuthor and PullRequestLink are not defined by drone. They are
+	// only set by CI systems providing them, like actions, or the context file.
+	PullRequestAuthor *domain.PullRequestAuthor
+	PullRequestLink   string `usage:"Link to the pull-request"`
+	PullRequestTitle  string `env:"DRONE_PULL_REQUEST_TITLE" usage:"Title of the pull-request"`
+	Remote            *domain.Remote
+	Repo              *domain.Repo
+	Semver            *domain.Semver
+	SourceBranch      string `env:"DRONE_SOURCE_BRANCH" usage:"Source branch of the pull-request"`
+	Stage             *domain.Stage
+	Step              *domain.Step
+	System            *domain.System
+	Tag               string `env:"DRONE_TAG" usage:"Tag"`
+	TargetBranch      string `env:"DRONE_TARGET_BRANCH" usage:"Target branch of the pull-request or push"`
+	Yaml              *domain.Yaml
+}
+
+// NewCIVars returns CI vars with all nested structs allocated, so that templates
+// can access all fields.
+func NewCIVars() *CIVars {
+	return &CIVars{
+		Build: new(domain.Build),
+		Commit: &domain.Commit{
+			Author: new(domain.Author),
+		},
+		Job: new(domain.Job),
+		Prev: &domain.Prev{
+			Build:  new(domain.PrevBuild),
+			Commit: new(domain.PrevCommit),
+		},
+		PullRequestAuthor: new(domain.PullRequestAuthor),
+		Remote:            new(domain.Remote),
+		Repo:              new(domain.Repo),
+		Semver:            new(domain.Semver),
+		Stage:             new(domain.Stage),
+		Step:              new(domain.Step),
+		System:            new(domain.System),
+		Yaml:              new(domain.Yaml),
+	}
+}
+
+// listUnsubscribe contains the values of the List-Unsubscribe headers defined
+// by RFC 2369 and RFC 8058.
+type listUnsubscribe struct {
+	URI string
+
+	// OneClick is true for HTTPS URIs, which support the one-click unsubscribe
+	// via List-Unsubscribe-Post.
+	OneClick bool
+}
+
+// StageLink returns the link to the current stage of the build. Drone lists the
+// failed steps of the current stage, therefore the failed steps are linked to
+// the stage. If the stage number is unknown, the link of the build is returned.
+func (c *CIVars) StageLink() string {
+	if c.Build == nil {
+		return ""
+	}
+	if c.Stage == nil || c.Stage.Number <= 0 || len(c.Build.Link) <= 0 {
+		return c.Build.Link
+	}
+
+	return fmt.Sprintf("%s/%d", strings.TrimSuffix(c.Build.Link, "/"), c.Stage.Number)
+}
+
+// Transition returns the transition of the build status compared to the
+// status of the previous build.
+func (c *CIVars) Transition() domain.Transition {
+	var prevStatus, status string
+	if c.Prev != nil && c.Prev.Build != nil {
+		prevStatus = c.Prev.Build.Status
+	}
+	if c.Build != nil {
+		status = c.Build.Status
+	}
+
+	return domain.NewTransition(prevStatus, status)
 }
 
 type templateVars struct {
-	CIVars       *CIVars
-	Recipient    string
-	SMTPSettings *domain.SMTPSettings
+	CIVars          *CIVars
+	Data            map[string]any
+	Deployment      *deployment
+	Details         *details
+	Escalation      *escalation
+	ListUnsubscribe *listUnsubscribe
+	PullRequest     *domain.PullRequest
+	Recipient       *netmail.Address
+	SMTPSettings    *domain.SMTPSettings
+	Vars            map[string]any
 }
 
 func (t *templateVars) TimeNowFormat(layout string) string {
@@ -53,53 +143,336 @@ func (t *templateVars) TimeNowFormat(layout string) string {
 }
 
 type Plugin struct {
-	smtpSettings *domain.SMTPSettings
+	deploymentSettings *domain.DeploymentSettings
+	digestSettings     *domain.DigestSettings
+	droneAPISettings   *domain.DroneAPISettings
+	dryRunSettings     *domain.DryRunSettings
+	escalationSettings *domain.EscalationSettings
+	filterSettings     *domain.FilterSettings
+	output             io.Writer
+	recipientSettings  *domain.RecipientSettings
+	scheduleSettings   *domain.ScheduleSettings
+	smtpSettings       *domain.SMTPSettings
+	stateSettings      *domain.StateSettings
+	templateSettings   *domain.TemplateSettings
+}
+
+// Settings are the settings of the plugin. Undefined settings are replaced by
+// their zero value.
+type Settings struct {
+	Deployment *domain.DeploymentSettings
+	Digest     *domain.DigestSettings
+	DroneAPI   *domain.DroneAPISettings
+	DryRun     *domain.DryRunSettings
+	Escalation *domain.EscalationSettings
+	Filter     *domain.FilterSettings
+	Recipient  *domain.RecipientSettings
+	Schedule   *domain.ScheduleSettings
+	SMTP       *domain.SMTPSettings
+	State      *domain.StateSettings
+	Template   *domain.TemplateSettings
+
+	// Output receives the mails of a dry run. Defaults to stdout.
+	Output io.Writer
+}
+
+// Message is a rendered mail for a single recipient.
+type Message struct {
+	Data      []byte
+	Recipient string
 }
 
-// Exec will send emails over SMTP
+// Exec will send emails over SMTP. If the conditions to send mails are not met,
+// the build is queued for the digest, an equivalent notification has already
+// been sent or the build finished outside of the schedule and the mails are not
+// sent to the on-call address, a SkipError is returned.
 func (p *Plugin) Exec(ctx context.Context, recipients []string, ciVars *CIVars) error {
-	exists := false
-	for _, recipient := range recipients {
-		if recipient == ciVars.Commit.Author.Email {
-			exists = true
-			break
+	switch p.digestSettings.Mode {
+	case domain.NotifyModeDigest, domain.NotifyModeInstant, "":
+	default:
+		return fmt.Errorf("unsupported notify mode %s", p.digestSettings.Mode)
+	}
+
+	err := p.validateFilters()
+	if err != nil {
+		return err
+	}
+
+	// The history must be recorded before the filters are checked. Otherwise
+	// skipped successful builds would not reset the consecutive failures and
+	// skipped builds would be missing in the changes of deployments.
+	now := time.Now()
+	esc, err := p.escalate(ciVars, now)
+	if err != nil {
+		return err
+	}
+
+	dep, err := p.deploy(ciVars, now)
+	if err != nil {
+		return err
+	}
+
+	err = p.checkFilters(ciVars)
+	if err != nil {
+		return err
+	}
+
+	if p.digestSettings.Enabled() {
+		err = p.queueDigest(ciVars, now)
+		if err != nil {
+			return err
+		}
+
+		return &SkipError{Reason: fmt.Sprintf("build queued for the digest in %s", p.digestSettings.Spool)}
+	}
+
+	err = p.checkState(ciVars, now)
+	if err != nil {
+		return err
+	}
+
+	inSchedule, err := p.checkSchedule(ciVars)
+	if err != nil {
+		return err
+	}
+
+	if dep != nil {
+		recipients = slices.Concat(recipients, dep.recipients)
+	}
+	if esc != nil {
+		recipients = slices.Concat(recipients, esc.recipients)
+	}
+
+	var rcpts *recipientSet
+	switch {
+	case inSchedule:
+		rcpts, err = p.resolveRecipients(recipients, ciVars)
+		if err != nil {
+			return fmt.Errorf("failed to resolve recipients: %w", err)
+		}
+	case p.scheduleSettings.Action == domain.ScheduleActionOnCall:
+		rcpts = newRecipientSet(nil)
+		err = rcpts.AddString(p.scheduleSettings.OnCallAddress)
+		if err != nil {
+			return fmt.Errorf("failed to resolve on-call recipient: %w", err)
 		}
+
+		rcpts, err = p.applyRecipientPolicy(rcpts, ciVars.Repo)
+		if err != nil {
+			return err
+		}
+
+		rcpts, err = p.applyOptOuts(rcpts, ciVars.Build)
+		if err != nil {
+			return err
+		}
+	case p.scheduleSettings.Action == domain.ScheduleActionSpool:
+		rcpts, err = p.resolveRecipients(recipients, ciVars)
+		if err != nil {
+			return fmt.Errorf("failed to resolve recipients: %w", err)
+		}
+	default:
+		return &SkipError{Reason: "build finished outside of the schedule"}
 	}
 
-	if !exists {
-		recipients = append(recipients, ciVars.Commit.Author.Email)
+	// The details are optional. If the drone API is not available, the mails are
+	// sent without them.
+	det, err := p.fetchDetails(ctx, ciVars)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: failed to fetch details of build: %v\n", err)
 	}
 
+	messages, err := p.render(rcpts, &templateVars{
+		CIVars:      ciVars,
+		Deployment:  dep,
+		Details:     det,
+		Escalation:  esc,
+		PullRequest: newPullRequest(ciVars),
+	})
+	if err != nil {
+		return &RenderError{Err: err}
+	}
+
+	err = p.reserveState(ciVars, now)
+	if err != nil {
+		return err
+	}
+
+	if !inSchedule && p.scheduleSettings.Action == domain.ScheduleActionSpool {
+		err = p.spool(messages)
+		if err != nil {
+			return p.releaseStateOnError(ciVars, now, err)
+		}
+
+		return &SkipError{
+			Reason: fmt.Sprintf("build finished outside of the schedule, %d mails spooled to %s", len(messages), p.scheduleSettings.SpoolDir),
+		}
+	}
+
+	err = p.send(ctx, messages)
+	if err != nil {
+		return p.releaseStateOnError(ciVars, now, err)
+	}
+
+	return nil
+}
+
+// Flush sends all mails of the spool directory and removes them afterwards. It
+// returns the number of sent mails.
+func (p *Plugin) Flush(ctx context.Context) (int, error) {
+	entries, err := spool.Read(p.scheduleSettings.SpoolDir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read spool: %w", err)
+	}
+
+	for i, entry := range entries {
+		err = p.send(ctx, []*Message{{Data: entry.Message.Data, Recipient: entry.Message.Recipient}})
+		if err != nil {
+			return i, err
+		}
+
+		if p.dryRunSettings.Enabled {
+			continue
+		}
+
+		err = entry.Remove()
+		if err != nil {
+			return i + 1, err
+		}
+	}
+
+	return len(entries), nil
+}
+
+// render renders the mail template for each recipient. Pull requests,
+// deployments and escalated failures override the subject and sections of the
+// mail template by their own templates. The values of vars are passed to the
+// templates of all recipients.
+func (p *Plugin) render(rcpts *recipientSet, vars *templateVars) ([]*Message, error) {
 	tpl, err := template.New("mail").Parse(mailTemplate)
 	if err != nil {
-		return fmt.Errorf("failed to parse template: %w", err)
+		return nil, fmt.Errorf("failed to parse template: %w", err)
 	}
 
-	buf := make([]byte, 0)
-	buffer := bytes.NewBuffer(buf)
+	if vars.PullRequest != nil {
+		tpl, err = tpl.Parse(pullRequestTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse pull request template: %w", err)
+		}
+	}
 
-	for _, recipient := range recipients {
-		err = tpl.Execute(buffer, &templateVars{
-			CIVars:       ciVars,
-			Recipient:    recipient,
-			SMTPSettings: p.smtpSettings,
-		})
+	if vars.Deployment != nil {
+		tpl, err = tpl.Parse(deploymentTemplate)
 		if err != nil {
-			return fmt.Errorf("failed to generate template: %w", err)
+			return nil, fmt.Errorf("failed to parse deployment template: %w", err)
 		}
+	}
 
-		err := p.sendMail(recipient, buffer)
+	if vars.Escalation != nil {
+		tpl, err = tpl.Parse(escalationTemplate)
 		if err != nil {
-			return fmt.Errorf("failed to send mail: %w", err)
+			return nil, fmt.Errorf("failed to parse escalation template: %w", err)
 		}
+	}
 
-		buffer.Reset()
+	data, err := p.readData()
+	if err != nil {
+		return nil, err
+	}
+
+	var listUnsubscribeTpl *template.Template
+	if len(p.smtpSettings.ListUnsubscribe) > 0 {
+		listUnsubscribeTpl, err = template.New("list-unsubscribe").Parse(p.smtpSettings.ListUnsubscribe)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse list unsubscribe template: %w", err)
+		}
+	}
+
+	messages := make([]*Message, 0, len(rcpts.Addresses()))
+	for _, recipient := range rcpts.Addresses() {
+		recipientVars := *vars
+		recipientVars.Data = data
+		recipientVars.Recipient = recipient
+		recipientVars.SMTPSettings = p.smtpSettings
+		recipientVars.Vars = p.templateSettings.Vars
+
+		if listUnsubscribeTpl != nil {
+			recipientVars.ListUnsubscribe, err = newListUnsubscribe(listUnsubscribeTpl, &recipientVars)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		buffer := new(bytes.Buffer)
+		err = tpl.Execute(buffer, &recipientVars)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate template: %w", err)
+		}
+
+		messages = append(messages, &Message{
+			Data:      buffer.Bytes(),
+			Recipient: recipient.Address,
+		})
+	}
+
+	return messages, nil
+}
+
+// send sends the messages over SMTP. In a dry run the messages are printed
+// instead. Errors of the SMTP session are returned as DeliveryError.
+func (p *Plugin) send(_ context.Context, messages []*Message) error {
+	for _, message := range messages {
+		if p.dryRunSettings.Enabled {
+			err := p.dryRunMail(message)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		err := p.sendMail(message.Recipient, bytes.NewReader(message.Data))
+		if err != nil {
+			return &DeliveryError{Err: fmt.Errorf("failed to send mail: %w", err)}
+		}
 	}
 
 	return nil
 }
 
-func (p *Plugin) sendMail(recipient string, r io.Reader) error {
+// spool writes the messages into the spool directory, which will be delivered
+// by Flush. In a dry run the messages are printed instead.
+func (p *Plugin) spool(messages []*Message) error {
+	if p.dryRunSettings.Enabled {
+		for _, message := range messages {
+			err := p.printMessage(fmt.Sprintf("E-Mail to %s spooled to %s", message.Recipient, p.scheduleSettings.SpoolDir), message)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	spooled := time.Now()
+	spoolMessages := make([]*spool.Message, 0, len(messages))
+	for _, message := range messages {
+		spoolMessages = append(spoolMessages, &spool.Message{
+			Data:      message.Data,
+			Recipient: message.Recipient,
+			Spooled:   spooled,
+		})
+	}
+
+	err := spool.Write(p.scheduleSettings.SpoolDir, spoolMessages...)
+	if err != nil {
+		return fmt.Errorf("failed to spool mails: %w", err)
+	}
+
+	return nil
+}
+
+// dial connects to the SMTP server and initializes an authenticated session.
+// The returned client must be closed by the caller.
+func (p *Plugin) dial() (*smtp.Client, error) {
 	// log.Printf("FROM_ADDRESS: %s", p.smtpSettings.FromAddress)
 	// log.Printf("FROM_NAME: %s", p.smtpSettings.FromName)
 	// log.Printf("HELO: %s", p.smtpSettings.HELOName)
@@ -113,19 +486,19 @@ func (p *Plugin) sendMail(recipient string, r io.Reader) error {
 	address := fmt.Sprintf("%v:%v", p.smtpSettings.Host, p.smtpSettings.Port)
 	tcpConn, err := net.Dial("tcp", address)
 	if err != nil {
-		return fmt.Errorf("failed to dial a connection to %s: %w", address, err)
+		return nil, fmt.Errorf("failed to dial a connection to %s: %w", address, err)
 	}
-	defer func() { _ = tcpConn.Close() }()
 
 	smtpClient, err := smtp.NewClient(tcpConn, p.smtpSettings.Host)
 	if err != nil {
-		return fmt.Errorf("failed to initialize a new smtp client: %w", err)
+		_ = tcpConn.Close()
+		return nil, fmt.Errorf("failed to initialize a new smtp client: %w", err)
 	}
-	defer func() { _ = smtpClient.Close() }()
 
 	err = smtpClient.Hello(p.smtpSettings.HELOName)
 	if err != nil {
-		return fmt.Errorf("failed to send helo command: %w", err)
+		_ = smtpClient.Close()
+		return nil, fmt.Errorf("failed to send helo command: %w", err)
 	}
 
 	// #nosec G402
@@ -135,15 +508,27 @@ func (p *Plugin) sendMail(recipient string, r io.Reader) error {
 		ServerName:         p.smtpSettings.Host,
 	})
 	if err != nil {
-		return fmt.Errorf("failed initialize starttls session: %w", err)
+		_ = smtpClient.Close()
+		return nil, fmt.Errorf("failed initialize starttls session: %w", err)
 	}
 
 	smtpAuth := smtp.PlainAuth(p.smtpSettings.FromAddress, p.smtpSettings.FromAddress, p.smtpSettings.Password, p.smtpSettings.Host)
 	err = smtpClient.Auth(smtpAuth)
 	if err != nil {
-		return fmt.Errorf("failed to authenticate client: %w", err)
+		_ = smtpClient.Close()
+		return nil, fmt.Errorf("failed to authenticate client: %w", err)
 	}
 
+	return smtpClient, nil
+}
+
+func (p *Plugin) sendMail(recipient string, r io.Reader) error {
+	smtpClient, err := p.dial()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = smtpClient.Close() }()
+
 	err = smtpClient.Mail(p.smtpSettings.FromAddress)
 	if err != nil {
 		return fmt.Errorf("failed to sent mail command: %w", err)
@@ -181,8 +566,187 @@ func (p *Plugin) sendMail(recipient string, r io.Reader) error {
 	return nil
 }
 
-func NewPlugin(config *domain.SMTPSettings) *Plugin {
-	return &Plugin{
-		smtpSettings: config,
+// resolveRecipients returns the de-duplicated set of the explicit recipients,
+// the conditional recipients whose expression evaluates to true, the commit
+// author and if configured the addresses of commit message
+// trailers. All sources are resolved by the team directory, if defined.
+// Afterwards the allow and deny lists and the opt-outs of the recipients are
+// applied.
+func (p *Plugin) resolveRecipients(recipients []string, ciVars *CIVars) (*recipientSet, error) {
+	var dir *directory.Directory
+	if len(p.recipientSettings.DirectoryFile) > 0 {
+		var err error
+		dir, err = directory.ReadFile(p.recipientSettings.DirectoryFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read team directory: %w", err)
+		}
+	}
+
+	rcpts := newRecipientSet(dir)
+	for _, recipient := range recipients {
+		err := rcpts.AddString(recipient)
+		if err != nil {
+			return nil, err
+		}
 	}
+
+	for _, conditional := range p.recipientSettings.Conditional {
+		ok, err := evalExpr(conditional.When, ciVars)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		err = rcpts.AddString(conditional.Address)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Pull requests are work in progress, therefore their mails can be sent to
+	// the author of the pull request instead of the author of the commit. If the
+	// author of the pull request is unknown or can not be resolved to an address,
+	// the author of the commit remains the recipient.
+	var pullRequestAuthorAdded bool
+	if pr := newPullRequest(ciVars); pr != nil && pr.Author != nil && p.recipientSettings.PullRequestAuthor {
+		pullRequestAuthorAdded = rcpts.AddAuthor(pr.Author.Author())
+	}
+	if !pullRequestAuthorAdded && ciVars.Commit != nil {
+		rcpts.AddAuthor(ciVars.Commit.Author)
+	}
+
+	if ciVars.Commit != nil {
+		trailerKeys := make([]string, 0, len(p.recipientSettings.Trailers)+1)
+		if p.recipientSettings.CoAuthors {
+			trailerKeys = append(trailerKeys, domain.TrailerCoAuthoredBy)
+		}
+		trailerKeys = append(trailerKeys, p.recipientSettings.Trailers...)
+
+		commitMessage := ciVars.Commit.ParsedMessage()
+		for _, trailerKey := range trailerKeys {
+			for _, author := range commitMessage.TrailerAuthors(trailerKey) {
+				rcpts.AddAuthor(author)
+			}
+		}
+	}
+
+	rcpts, err := p.applyRecipientPolicy(rcpts, ciVars.Repo)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.applyOptOuts(rcpts, ciVars.Build)
+}
+
+// applyOptOuts drops all recipients which opted out of mails for the build
+// status.
+func (p *Plugin) applyOptOuts(rcpts *recipientSet, build *domain.Build) (*recipientSet, error) {
+	if len(p.recipientSettings.OptOutFile) <= 0 {
+		return rcpts, nil
+	}
+
+	optOuts, err := optout.ReadFile(p.recipientSettings.OptOutFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read opt-out file: %w", err)
+	}
+
+	status := ""
+	if build != nil {
+		status = build.Status
+	}
+
+	result := newRecipientSet(nil)
+	for _, recipient := range rcpts.Addresses() {
+		if optOuts.Contains(recipient.Address, status) {
+			_, _ = fmt.Fprintf(os.Stderr, "Warning: dropped recipient %s: recipient opted out of %s mails\n", recipient.Address, status)
+			continue
+		}
+		result.Add(recipient.Address, recipient.Name)
+	}
+
+	return result, nil
+}
+
+// newListUnsubscribe renders the list unsubscribe template for a recipient.
+func newListUnsubscribe(tpl *template.Template, vars *templateVars) (*listUnsubscribe, error) {
+	buffer := new(bytes.Buffer)
+	err := tpl.Execute(buffer, vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate list unsubscribe template: %w", err)
+	}
+
+	uri := strings.TrimSpace(buffer.String())
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse list unsubscribe uri %s: %w", uri, err)
+	}
+
+	switch u.Scheme {
+	case "mailto", "http", "https":
+	default:
+		return nil, fmt.Errorf("unsupported scheme of list unsubscribe uri %s: expected mailto, http or https", uri)
+	}
+
+	return &listUnsubscribe{
+		URI:      uri,
+		OneClick: u.Scheme == "https",
+	}, nil
+}
+
+func NewPlugin(settings *Settings) *Plugin {
+	p := &Plugin{
+		deploymentSettings: settings.Deployment,
+		digestSettings:     settings.Digest,
+		droneAPISettings:   settings.DroneAPI,
+		dryRunSettings:     settings.DryRun,
+		escalationSettings: settings.Escalation,
+		filterSettings:     settings.Filter,
+		output:             settings.Output,
+		recipientSettings:  settings.Recipient,
+		scheduleSettings:   settings.Schedule,
+		smtpSettings:       settings.SMTP,
+		stateSettings:      settings.State,
+		templateSettings:   settings.Template,
+	}
+
+	if p.deploymentSettings == nil {
+		p.deploymentSettings = new(domain.DeploymentSettings)
+	}
+	if p.digestSettings == nil {
+		p.digestSettings = new(domain.DigestSettings)
+	}
+	if p.droneAPISettings == nil {
+		p.droneAPISettings = new(domain.DroneAPISettings)
+	}
+	if p.dryRunSettings == nil {
+		p.dryRunSettings = new(domain.DryRunSettings)
+	}
+	if p.escalationSettings == nil {
+		p.escalationSettings = new(domain.EscalationSettings)
+	}
+	if p.filterSettings == nil {
+		p.filterSettings = new(domain.FilterSettings)
+	}
+	if p.output == nil {
+		p.output = os.Stdout
+	}
+	if p.recipientSettings == nil {
+		p.recipientSettings = new(domain.RecipientSettings)
+	}
+	if p.scheduleSettings == nil {
+		p.scheduleSettings = new(domain.ScheduleSettings)
+	}
+	if p.smtpSettings == nil {
+		p.smtpSettings = new(domain.SMTPSettings)
+	}
+	if p.stateSettings == nil {
+		p.stateSettings = new(domain.StateSettings)
+	}
+	if p.templateSettings == nil {
+		p.templateSettings = new(domain.TemplateSettings)
+	}
+
+	return p
 }
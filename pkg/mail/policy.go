@@ -0,0 +1,84 @@
+package mail
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/domain"
+)
+
+// addressList is a list of addresses and domains. Domains can be prefixed with
+// `*.` to match all subdomains.
+type addressList []string
+
+// Contains returns true if the address or its domain is part of the list.
+func (l addressList) Contains(address string) bool {
+	address = strings.ToLower(strings.TrimSpace(address))
+	domain := address[strings.LastIndex(address, "@")+1:]
+
+	for _, entry := range l {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case len(entry) <= 0:
+			continue
+		case strings.Contains(entry, "@") && !strings.HasPrefix(entry, "@"):
+			if entry == address {
+				return true
+			}
+		case strings.HasPrefix(entry, "*."):
+			if strings.HasSuffix(domain, entry[1:]) {
+				return true
+			}
+		default:
+			if strings.TrimPrefix(entry, "@") == domain {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// applyRecipientPolicy drops all recipients which are part of the deny list or,
+// if an allow list is defined, not part of the allow list. The allow list can
+// be limited to private repositories. Each dropped recipient is logged and
+// replaced by the fallback address, if defined.
+func (p *Plugin) applyRecipientPolicy(rcpts *recipientSet, repo *domain.Repo) (*recipientSet, error) {
+	allowList := addressList(p.recipientSettings.AllowList)
+	denyList := addressList(p.recipientSettings.DenyList)
+
+	applyAllowList := len(allowList) > 0
+	if p.recipientSettings.AllowListPrivateOnly {
+		applyAllowList = applyAllowList && repo != nil && repo.Private
+	}
+
+	result := newRecipientSet(nil)
+	for _, recipient := range rcpts.Addresses() {
+		reason := ""
+		switch {
+		case denyList.Contains(recipient.Address):
+			reason = "address is part of the deny list"
+		case applyAllowList && !allowList.Contains(recipient.Address):
+			reason = "address is not part of the allow list"
+		}
+
+		if len(reason) <= 0 {
+			result.Add(recipient.Address, recipient.Name)
+			continue
+		}
+
+		if len(p.recipientSettings.FallbackAddress) <= 0 {
+			_, _ = fmt.Fprintf(os.Stderr, "Warning: dropped recipient %s: %s\n", recipient.Address, reason)
+			continue
+		}
+
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: dropped recipient %s: %s, redirect to %s\n", recipient.Address, reason, p.recipientSettings.FallbackAddress)
+		err := result.AddString(p.recipientSettings.FallbackAddress)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,43 @@
+package mail
+
+import (
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/domain"
+
+	_ "embed"
+)
+
+//go:embed assets/pullrequest.txt
+var pullRequestTemplate string
+
+// newPullRequest returns the pull request of the build, which is passed to the
+// pull request template. If the build is not a build of a pull request, nil is
+// returned.
+func newPullRequest(ciVars *CIVars) *domain.PullRequest {
+	if ciVars.PullRequest <= 0 {
+		return nil
+	}
+
+	pr := &domain.PullRequest{
+		Link:         ciVars.PullRequestLink,
+		Number:       ciVars.PullRequest,
+		SourceBranch: ciVars.SourceBranch,
+		TargetBranch: ciVars.TargetBranch,
+		Title:        ciVars.PullRequestTitle,
+	}
+	if ciVars.PullRequestAuthor.IsDefined() {
+		pr.Author = ciVars.PullRequestAuthor
+	}
+	if ciVars.Commit != nil {
+		if len(pr.Title) <= 0 {
+			pr.Title = ciVars.Commit.ParsedMessage().Subject
+		}
+		if len(pr.TargetBranch) <= 0 {
+			pr.TargetBranch = ciVars.Commit.Branch
+		}
+	}
+	if len(pr.Link) <= 0 && ciVars.Repo != nil {
+		pr.Link = domain.PullRequestLink(ciVars.Repo.SCM, ciVars.Repo.Link, pr.Number)
+	}
+
+	return pr
+}
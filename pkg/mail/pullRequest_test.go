@@ -0,0 +1,136 @@
+package mail
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/domain"
+)
+
+func newPullRequestCIVars() *CIVars {
+	ciVars := NewCIVars()
+	ciVars.Build.Status = domain.StatusFailure
+	ciVars.Commit.Author.Email = "committer@example.com"
+	ciVars.Commit.Branch = "master"
+	ciVars.Commit.Message = "feat: add feature"
+	ciVars.PullRequest = 12
+	ciVars.Repo.Link = "https://github.com/volker.raschek/drone-email"
+	ciVars.Repo.SCM = domain.SCMGitHub
+	ciVars.SourceBranch = "feature"
+	return ciVars
+}
+
+func TestNewPullRequest(t *testing.T) {
+	ciVars := newPullRequestCIVars()
+
+	pr := newPullRequest(ciVars)
+	if pr.Author != nil {
+		t.Errorf("expected no author, got %+v", pr.Author)
+	}
+	if expected := "https://github.com/volker.raschek/drone-email/pull/12"; pr.Link != expected {
+		t.Errorf("expected derived link %s, got %s", expected, pr.Link)
+	}
+	if pr.Title != "feat: add feature" || pr.TargetBranch != "master" {
+		t.Errorf("expected title and target branch of the commit, got %s and %s", pr.Title, pr.TargetBranch)
+	}
+
+	ciVars.PullRequestAuthor.Username = "erika"
+	ciVars.PullRequestLink = "https://github.com/volker.raschek/drone-email/pull/12/files"
+	ciVars.PullRequestTitle = "Add feature"
+
+	pr = newPullRequest(ciVars)
+	if pr.Author == nil || pr.Author.Username != "erika" {
+		t.Errorf("expected author erika, got %+v", pr.Author)
+	}
+	if pr.Link != ciVars.PullRequestLink {
+		t.Errorf("expected link of the CI system %s, got %s", ciVars.PullRequestLink, pr.Link)
+	}
+	if pr.Title != "Add feature" {
+		t.Errorf("expected title Add feature, got %s", pr.Title)
+	}
+
+	ciVars.PullRequest = 0
+	if pr := newPullRequest(ciVars); pr != nil {
+		t.Errorf("expected no pull request, got %+v", pr)
+	}
+}
+
+func TestResolveRecipientsPullRequestAuthor(t *testing.T) {
+	directoryFile := filepath.Join(t.TempDir(), "directory.yaml")
+	err := os.WriteFile(directoryFile, []byte("members:\n- email: erika@example.com\n  usernames: [erika]\n"), 0o600)
+	if err != nil {
+		t.Fatalf("failed to write team directory: %v", err)
+	}
+
+	testCases := []struct {
+		name              string
+		pullRequestAuthor bool
+		authorEmail       string
+		authorUsername    string
+		directoryFile     string
+		expected          []string
+	}{
+		{
+			name:        "commit author",
+			authorEmail: "erika@example.com",
+			expected:    []string{"team@example.com", "qa@example.com", "committer@example.com"},
+		},
+		{
+			name:              "pull request author",
+			pullRequestAuthor: true,
+			authorEmail:       "erika@example.com",
+			expected:          []string{"team@example.com", "qa@example.com", "erika@example.com"},
+		},
+		{
+			name:              "unknown pull request author",
+			pullRequestAuthor: true,
+			expected:          []string{"team@example.com", "qa@example.com", "committer@example.com"},
+		},
+		{
+			name:              "unresolved username of pull request author",
+			pullRequestAuthor: true,
+			authorUsername:    "erika",
+			expected:          []string{"team@example.com", "qa@example.com", "committer@example.com"},
+		},
+		{
+			name:              "username of pull request author resolved by team directory",
+			pullRequestAuthor: true,
+			authorUsername:    "erika",
+			directoryFile:     directoryFile,
+			expected:          []string{"team@example.com", "qa@example.com", "erika@example.com"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			p := NewPlugin(&Settings{
+				Recipient: &domain.RecipientSettings{
+					Conditional: []*domain.ConditionalRecipient{
+						{Address: "qa@example.com", When: "pull_request > 0"},
+					},
+					DirectoryFile:     testCase.directoryFile,
+					PullRequestAuthor: testCase.pullRequestAuthor,
+				},
+			})
+
+			ciVars := newPullRequestCIVars()
+			ciVars.PullRequestAuthor.Email = testCase.authorEmail
+			ciVars.PullRequestAuthor.Username = testCase.authorUsername
+
+			rcpts, err := p.resolveRecipients([]string{"team@example.com"}, ciVars)
+			if err != nil {
+				t.Fatalf("failed to resolve recipients: %v", err)
+			}
+
+			actual := make([]string, 0)
+			for _, address := range rcpts.Addresses() {
+				actual = append(actual, address.Address)
+			}
+			if !slices.Equal(actual, testCase.expected) {
+				t.Errorf("expected %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package mail
+
+import (
+	"fmt"
+	netmail "net/mail"
+	"strings"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/directory"
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/domain"
+)
+
+// recipientSet is an ordered set of mail addresses. Addresses are compared
+// case-insensitive, the first added display name wins. If a team directory is
+// defined, usernames, alternative addresses and group aliases are resolved to
+// the canonical addresses of the directory members.
+type recipientSet struct {
+	addresses []*netmail.Address
+	directory *directory.Directory
+	index     map[string]int
+}
+
+// Add adds the address to the set, unless the address is empty or already
+// part of the set. An already known address without display name receives the
+// passed name.
+func (r *recipientSet) Add(address string, name string) {
+	address = strings.TrimSpace(address)
+	if len(address) <= 0 {
+		return
+	}
+
+	if r.directory != nil {
+		if member, ok := r.directory.LookupEmail(address); ok {
+			address, name = memberAddress(member, name)
+		}
+	}
+
+	key := strings.ToLower(address)
+	if i, ok := r.index[key]; ok {
+		if len(r.addresses[i].Name) <= 0 {
+			r.addresses[i].Name = name
+		}
+		return
+	}
+
+	r.index[key] = len(r.addresses)
+	r.addresses = append(r.addresses, &netmail.Address{
+		Address: address,
+		Name:    name,
+	})
+}
+
+// AddAuthor adds the author to the set. The author is looked up in the team
+// directory by its address and afterwards by its username. It returns false, if
+// the author could not be resolved to an address, for example a username
+// without team directory.
+func (r *recipientSet) AddAuthor(author *domain.Author) bool {
+	if author == nil {
+		return false
+	}
+
+	if r.directory != nil && len(author.Username) > 0 {
+		if _, ok := r.directory.LookupEmail(author.Email); !ok {
+			if member, ok := r.directory.LookupUsername(author.Username); ok {
+				r.Add(memberAddress(member, author.Name))
+				return true
+			}
+		}
+	}
+
+	r.Add(author.Email, author.Name)
+
+	return len(strings.TrimSpace(author.Email)) > 0
+}
+
+// AddString adds a recipient to the set. The recipient is either a RFC 5322
+// address, for example `John Doe <john@example.com>` or `john@example.com`, or
+// when a team directory is defined, a username or group alias like @backend.
+func (r *recipientSet) AddString(s string) error {
+	s = strings.TrimSpace(s)
+	if len(s) <= 0 {
+		return nil
+	}
+
+	switch {
+	case strings.HasPrefix(s, directory.GroupPrefix):
+		if r.directory == nil {
+			return fmt.Errorf("failed to resolve group %s: no team directory defined", s)
+		}
+
+		members, ok := r.directory.LookupGroup(s)
+		if !ok {
+			return fmt.Errorf("failed to resolve group %s: %w", s, directory.ErrUnknownGroup)
+		}
+
+		for _, member := range members {
+			r.Add(memberAddress(member, ""))
+		}
+	case !strings.Contains(s, "@"):
+		if r.directory == nil {
+			return fmt.Errorf("failed to resolve username %s: no team directory defined", s)
+		}
+
+		member, ok := r.directory.LookupUsername(s)
+		if !ok {
+			return fmt.Errorf("failed to resolve username %s: unknown username", s)
+		}
+
+		r.Add(memberAddress(member, ""))
+	default:
+		address, err := netmail.ParseAddress(s)
+		if err != nil {
+			return fmt.Errorf("failed to parse address %s: %w", s, err)
+		}
+
+		r.Add(address.Address, address.Name)
+	}
+
+	return nil
+}
+
+func (r *recipientSet) Addresses() []*netmail.Address {
+	return r.addresses
+}
+
+// memberAddress returns the canonical address and display name of the member.
+// The passed name is used when the member has no display name.
+func memberAddress(member *directory.Member, name string) (string, string) {
+	if len(member.Name) > 0 {
+		name = member.Name
+	}
+
+	return member.Email, name
+}
+
+func newRecipientSet(dir *directory.Directory) *recipientSet {
+	return &recipientSet{
+		addresses: make([]*netmail.Address, 0),
+		directory: dir,
+		index:     make(map[string]int),
+	}
+}
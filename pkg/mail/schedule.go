@@ -0,0 +1,52 @@
+package mail
+
+import (
+	"fmt"
+	"time"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/domain"
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/schedule"
+)
+
+// checkSchedule returns true if the build finished inside of the schedule or no
+// schedule is defined. The time the build finished is used instead of the
+// current time, so that restarted builds behave consistently.
+func (p *Plugin) checkSchedule(ciVars *CIVars) (bool, error) {
+	if !p.scheduleSettings.Enabled() {
+		return true, nil
+	}
+
+	switch p.scheduleSettings.Action {
+	case domain.ScheduleActionSkip, "":
+	case domain.ScheduleActionOnCall:
+		if len(p.scheduleSettings.OnCallAddress) <= 0 {
+			return false, fmt.Errorf("schedule action %s requires an on-call address", p.scheduleSettings.Action)
+		}
+	case domain.ScheduleActionSpool:
+		if len(p.scheduleSettings.SpoolDir) <= 0 {
+			return false, fmt.Errorf("schedule action %s requires a spool directory", p.scheduleSettings.Action)
+		}
+	default:
+		return false, fmt.Errorf("unsupported schedule action %s", p.scheduleSettings.Action)
+	}
+
+	s, err := schedule.New(p.scheduleSettings.Windows, p.scheduleSettings.Weekdays, p.scheduleSettings.HolidayFile, p.scheduleSettings.Timezone)
+	if err != nil {
+		return false, fmt.Errorf("failed to initialize schedule: %w", err)
+	}
+
+	return s.Contains(buildTime(ciVars)), nil
+}
+
+// buildTime returns the time the build finished. If unknown, the time the build
+// started or the current time is returned.
+func buildTime(ciVars *CIVars) time.Time {
+	switch {
+	case ciVars.Build != nil && ciVars.Build.Finished > 0:
+		return time.Unix(ciVars.Build.Finished, 0)
+	case ciVars.Build != nil && ciVars.Build.Started > 0:
+		return time.Unix(ciVars.Build.Started, 0)
+	default:
+		return time.Now()
+	}
+}
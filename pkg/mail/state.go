@@ -0,0 +1,104 @@
+package mail
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/state"
+)
+
+// checkState returns a SkipError if an equivalent notification has been sent
+// within the window of the state settings.
+func (p *Plugin) checkState(ciVars *CIVars, now time.Time) error {
+	if !p.stateSettings.Enabled() {
+		return nil
+	}
+
+	store, err := state.Open(p.stateSettings.File)
+	if err != nil {
+		return fmt.Errorf("failed to open state store: %w", err)
+	}
+
+	return p.lookupState(store, ciVars, now)
+}
+
+// lookupState returns a SkipError if the store contains an equivalent
+// notification sent within the window of the state settings.
+func (p *Plugin) lookupState(store *state.Store, ciVars *CIVars, now time.Time) error {
+	entry := store.Lookup(newStateEntry(ciVars, now))
+	if entry != nil && now.Sub(entry.Sent) < p.stateSettings.Window {
+		return &SkipError{
+			Reason: fmt.Sprintf("equivalent notification for commit %s with status %s already sent at %s", entry.Commit, entry.Status, entry.Sent.Format(time.RFC3339)),
+		}
+	}
+
+	return nil
+}
+
+// reserveState checks the state store again and adds the notification to it
+// before the mails are sent, while the store is locked. Therefore concurrent
+// pipelines of the same commit can not both send the notification. Entries,
+// which are older than the window, are pruned. In a dry run the state store is
+// not modified.
+func (p *Plugin) reserveState(ciVars *CIVars, now time.Time) error {
+	if !p.stateSettings.Enabled() || p.dryRunSettings.Enabled {
+		return nil
+	}
+
+	return state.Update(p.stateSettings.File, func(store *state.Store) error {
+		err := p.lookupState(store, ciVars, now)
+		if err != nil {
+			return err
+		}
+
+		store.Prune(now.Add(-p.stateSettings.Window))
+		store.Put(newStateEntry(ciVars, now))
+
+		return nil
+	})
+}
+
+// releaseState removes the notification reserved by reserveState from the state
+// store, because the mails could not be sent.
+func (p *Plugin) releaseState(ciVars *CIVars, now time.Time) error {
+	if !p.stateSettings.Enabled() || p.dryRunSettings.Enabled {
+		return nil
+	}
+
+	return state.Update(p.stateSettings.File, func(store *state.Store) error {
+		store.Remove(newStateEntry(ciVars, now))
+		return nil
+	})
+}
+
+// releaseStateOnError releases the reserved notification and returns err. A
+// failed release is reported as warning, because err is the cause of the
+// failure.
+func (p *Plugin) releaseStateOnError(ciVars *CIVars, now time.Time, err error) error {
+	releaseErr := p.releaseState(ciVars, now)
+	if releaseErr != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: failed to release notification of state store: %v\n", releaseErr)
+	}
+
+	return err
+}
+
+// newStateEntry returns the state entry of the notification.
+func newStateEntry(ciVars *CIVars, now time.Time) *state.Entry {
+	entry := &state.Entry{
+		Sent: now,
+	}
+	if ciVars.Build != nil {
+		entry.Status = ciVars.Build.Status
+	}
+	if ciVars.Commit != nil {
+		entry.Branch = ciVars.Commit.Branch
+		entry.Commit = ciVars.Commit.Sha
+	}
+	if ciVars.Repo != nil {
+		entry.Repo = ciVars.Repo.FullName
+	}
+
+	return entry
+}
@@ -0,0 +1,81 @@
+package optout
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// OptOut is the preference of a recipient not to receive mails. If Statuses is
+// empty, the recipient does not receive any mail, otherwise only mails of
+// builds with one of the listed statuses are suppressed.
+type OptOut struct {
+	Address  string   `yaml:"address"`
+	Statuses []string `yaml:"statuses"`
+}
+
+type List struct {
+	optOuts map[string][]*OptOut
+}
+
+// Contains returns true if the recipient opted out of mails for the build
+// status.
+func (l *List) Contains(address string, status string) bool {
+	for _, optOut := range l.optOuts[normalize(address)] {
+		if len(optOut.Statuses) <= 0 {
+			return true
+		}
+
+		for _, s := range optOut.Statuses {
+			if strings.EqualFold(s, status) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+type file struct {
+	OptOuts []*OptOut `yaml:"optOuts"`
+}
+
+// ReadFile reads an opt-out list from a YAML or JSON file.
+func ReadFile(name string) (*List, error) {
+	// #nosec G304
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	optOutFile := new(file)
+	err = yaml.NewDecoder(f).Decode(optOutFile)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to decode %s: %w", name, err)
+	}
+
+	return New(optOutFile.OptOuts), nil
+}
+
+// New returns a list of the opt-outs.
+func New(optOuts []*OptOut) *List {
+	l := &List{
+		optOuts: make(map[string][]*OptOut),
+	}
+
+	for _, optOut := range optOuts {
+		key := normalize(optOut.Address)
+		l.optOuts[key] = append(l.optOuts[key], optOut)
+	}
+
+	return l
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
@@ -0,0 +1,302 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/domain"
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/mail"
+)
+
+// actions maps the GITHUB_* environment variables and the event payload of
+// GitHub Actions. Gitea and Forgejo Actions use the same variables, but link
+// their runs by the run number instead of the run ID.
+type actions struct{}
+
+type actionsUser struct {
+	AvatarURL string `json:"avatar_url"`
+	Email     string `json:"email"`
+	Login     string `json:"login"`
+	Name      string `json:"name"`
+	Username  string `json:"username"`
+}
+
+type actionsCommit struct {
+	Author  *actionsUser `json:"author"`
+	ID      string       `json:"id"`
+	Message string       `json:"message"`
+	URL     string       `json:"url"`
+}
+
+type actionsRepository struct {
+	CloneURL      string       `json:"clone_url"`
+	DefaultBranch string       `json:"default_branch"`
+	FullName      string       `json:"full_name"`
+	HTMLURL       string       `json:"html_url"`
+	Name          string       `json:"name"`
+	Owner         *actionsUser `json:"owner"`
+	Private       bool         `json:"private"`
+}
+
+type actionsPullRequest struct {
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+	Head struct {
+		Ref string `json:"ref"`
+		Sha string `json:"sha"`
+	} `json:"head"`
+	HTMLURL string       `json:"html_url"`
+	Number  int          `json:"number"`
+	Title   string       `json:"title"`
+	User    *actionsUser `json:"user"`
+}
+
+type actionsRelease struct {
+	Author  *actionsUser `json:"author"`
+	Body    string       `json:"body"`
+	HTMLURL string       `json:"html_url"`
+	Name    string       `json:"name"`
+	TagName string       `json:"tag_name"`
+}
+
+type actionsWorkflowRun struct {
+	Conclusion   string         `json:"conclusion"`
+	CreatedAt    time.Time      `json:"created_at"`
+	HeadBranch   string         `json:"head_branch"`
+	HeadCommit   *actionsCommit `json:"head_commit"`
+	HeadSha      string         `json:"head_sha"`
+	HTMLURL      string         `json:"html_url"`
+	RunNumber    int            `json:"run_number"`
+	RunStartedAt time.Time      `json:"run_started_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+// actionsEvent is the webhook payload of the event, which triggered the
+// workflow. Only the fields of the events push, pull_request, release and
+// workflow_run are decoded.
+type actionsEvent struct {
+	After       string              `json:"after"`
+	Before      string              `json:"before"`
+	HeadCommit  *actionsCommit      `json:"head_commit"`
+	PullRequest *actionsPullRequest `json:"pull_request"`
+	Release     *actionsRelease     `json:"release"`
+	Repository  *actionsRepository  `json:"repository"`
+	Sender      *actionsUser        `json:"sender"`
+	WorkflowRun *actionsWorkflowRun `json:"workflow_run"`
+}
+
+func (a *actions) Detect(getenv func(string) string) bool {
+	return getenv("GITHUB_ACTIONS") == "true"
+}
+
+func (a *actions) Load(getenv func(string) string) (*mail.CIVars, error) {
+	env := &environ{getenv: getenv}
+
+	event := new(actionsEvent)
+	if eventPath := env.String("GITHUB_EVENT_PATH"); len(eventPath) > 0 {
+		// #nosec G304
+		b, err := os.ReadFile(eventPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read event payload: %w", err)
+		}
+
+		err = json.Unmarshal(b, event)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode event payload %s: %w", eventPath, err)
+		}
+	}
+
+	serverURL := strings.TrimSuffix(env.String("GITHUB_SERVER_URL"), "/")
+	fullName := env.String("GITHUB_REPOSITORY")
+	repoLink := fmt.Sprintf("%s/%s", serverURL, fullName)
+	runNumber := env.Int("GITHUB_RUN_NUMBER")
+
+	ciVars := mail.NewCIVars()
+
+	ciVars.Build.Event = actionsEventName(env.String("GITHUB_EVENT_NAME"), env.String("GITHUB_REF_TYPE"))
+	ciVars.Build.Number = runNumber
+	// The status of the job is not available as environment variable. It must
+	// be passed via DRONE_BUILD_STATUS, except for workflow_run events.
+	if len(env.String("GITEA_ACTIONS", "FORGEJO_ACTIONS")) > 0 {
+		ciVars.Build.Link = fmt.Sprintf("%s/actions/runs/%d", repoLink, runNumber)
+	} else {
+		ciVars.Build.Link = fmt.Sprintf("%s/actions/runs/%s", repoLink, env.String("GITHUB_RUN_ID"))
+	}
+
+	ciVars.Commit.After = event.After
+	ciVars.Commit.Author.Name = env.String("GITHUB_ACTOR")
+	ciVars.Commit.Author.Username = env.String("GITHUB_ACTOR")
+	ciVars.Commit.Before = event.Before
+	ciVars.Commit.Ref = env.String("GITHUB_REF")
+	ciVars.Commit.Sha = env.String("GITHUB_SHA")
+	if env.String("GITHUB_REF_TYPE") == "tag" {
+		ciVars.Tag = env.String("GITHUB_REF_NAME")
+	} else {
+		ciVars.Commit.Branch = env.String("GITHUB_REF_NAME")
+	}
+	if event.Sender != nil {
+		ciVars.Commit.Author.Avatar = event.Sender.AvatarURL
+	}
+	if event.HeadCommit != nil {
+		setActionsCommit(ciVars.Commit, event.HeadCommit)
+	}
+
+	ciVars.Remote.URL = repoLink + ".git"
+
+	ciVars.Repo.FullName = fullName
+	ciVars.Repo.Link = repoLink
+	ciVars.Repo.Owner = env.String("GITHUB_REPOSITORY_OWNER")
+	ciVars.Repo.Name = strings.TrimPrefix(fullName, ciVars.Repo.Owner+"/")
+	switch {
+	case len(env.String("FORGEJO_ACTIONS")) > 0:
+		ciVars.Repo.SCM = domain.SCMForgejo
+	case len(env.String("GITEA_ACTIONS")) > 0:
+		ciVars.Repo.SCM = domain.SCMGitea
+	default:
+		ciVars.Repo.SCM = domain.SCMGitHub
+	}
+	if event.Repository != nil {
+		ciVars.Repo.Branch = event.Repository.DefaultBranch
+		ciVars.Repo.Private = event.Repository.Private
+		if len(event.Repository.CloneURL) > 0 {
+			ciVars.Remote.URL = event.Repository.CloneURL
+		}
+		if event.Repository.Owner != nil {
+			ciVars.Repo.Avatar = event.Repository.Owner.AvatarURL
+		}
+	}
+
+	// The commit remains the head commit of the pull request. The title, link and
+	// author of the pull request are only set on the pull request variables, so
+	// that neither the trailers of the commit nor its link are replaced.
+	if pr := event.PullRequest; pr != nil {
+		ciVars.Commit.Branch = pr.Base.Ref
+		ciVars.Commit.Sha = pr.Head.Sha
+		ciVars.PullRequest = pr.Number
+		ciVars.PullRequestLink = pr.HTMLURL
+		ciVars.PullRequestTitle = pr.Title
+		ciVars.SourceBranch = pr.Head.Ref
+		ciVars.TargetBranch = pr.Base.Ref
+		if pr.User != nil {
+			ciVars.PullRequestAuthor.Avatar = pr.User.AvatarURL
+			ciVars.PullRequestAuthor.Email = pr.User.Email
+			ciVars.PullRequestAuthor.Name = firstNonEmpty(pr.User.Name, pr.User.Login, pr.User.Username)
+			ciVars.PullRequestAuthor.Username = firstNonEmpty(pr.User.Login, pr.User.Username)
+		}
+	}
+
+	if release := event.Release; release != nil {
+		ciVars.Commit.Link = release.HTMLURL
+		ciVars.Commit.Message = strings.TrimSpace(release.Name + "\n\n" + release.Body)
+		ciVars.Tag = release.TagName
+		if release.Author != nil {
+			setActionsAuthor(ciVars.Commit.Author, release.Author)
+		}
+	}
+
+	// A workflow triggered by workflow_run reports the completed run of another
+	// workflow, including its conclusion.
+	if run := event.WorkflowRun; run != nil {
+		ciVars.Build.Created = unixTime(run.CreatedAt)
+		ciVars.Build.Finished = unixTime(run.UpdatedAt)
+		ciVars.Build.Link = run.HTMLURL
+		ciVars.Build.Number = run.RunNumber
+		ciVars.Build.Started = unixTime(run.RunStartedAt)
+		ciVars.Build.Status = run.Conclusion
+		ciVars.Commit.Branch = run.HeadBranch
+		ciVars.Commit.Sha = run.HeadSha
+		if run.HeadCommit != nil {
+			setActionsCommit(ciVars.Commit, run.HeadCommit)
+		}
+	}
+
+	if len(ciVars.Commit.Link) <= 0 && len(ciVars.Commit.Sha) > 0 {
+		ciVars.Commit.Link = fmt.Sprintf("%s/commit/%s", repoLink, ciVars.Commit.Sha)
+	}
+
+	ciVars.SourceBranch = firstNonEmpty(ciVars.SourceBranch, env.String("GITHUB_HEAD_REF"))
+	ciVars.TargetBranch = firstNonEmpty(ciVars.TargetBranch, env.String("GITHUB_BASE_REF"))
+
+	ciVars.Stage.Arch = strings.ToLower(env.String("RUNNER_ARCH"))
+	ciVars.Stage.Name = env.String("GITHUB_JOB")
+	ciVars.Stage.OS = strings.ToLower(env.String("RUNNER_OS"))
+
+	if u, err := url.Parse(serverURL); err == nil {
+		ciVars.System.Host = u.Host
+		ciVars.System.Hostname = u.Hostname()
+		ciVars.System.Proto = u.Scheme
+	}
+
+	err := env.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return ciVars, nil
+}
+
+func (a *actions) Name() string {
+	return NameActions
+}
+
+// actionsEventName returns the name of the drone event of the actions event.
+// Events without drone equivalent, like release or workflow_run, are returned
+// unchanged.
+func actionsEventName(eventName string, refType string) string {
+	switch eventName {
+	case "push":
+		if refType == "tag" {
+			return "tag"
+		}
+		return "push"
+	case "pull_request", "pull_request_target":
+		return "pull_request"
+	case "schedule":
+		return "cron"
+	case "workflow_dispatch":
+		return "custom"
+	default:
+		return eventName
+	}
+}
+
+func setActionsAuthor(author *domain.Author, user *actionsUser) {
+	author.Avatar = firstNonEmpty(user.AvatarURL, author.Avatar)
+	author.Email = firstNonEmpty(user.Email, author.Email)
+	author.Name = firstNonEmpty(user.Name, user.Login, user.Username, author.Name)
+	author.Username = firstNonEmpty(user.Login, user.Username, author.Username)
+}
+
+func setActionsCommit(commit *domain.Commit, c *actionsCommit) {
+	commit.Link = firstNonEmpty(c.URL, commit.Link)
+	commit.Message = c.Message
+	commit.Sha = firstNonEmpty(c.ID, commit.Sha)
+	if c.Author != nil {
+		setActionsAuthor(commit.Author, c.Author)
+	}
+}
+
+// unixTime returns the unix timestamp of the time or 0, if the time is not
+// defined.
+func unixTime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.Unix()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if len(v) > 0 {
+			return v
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,173 @@
+package provider
+
+import (
+	"path/filepath"
+	"testing"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/domain"
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/mail"
+)
+
+// newGetenv returns a getenv function, which looks up the environment variables
+// of the map instead of the process.
+func newGetenv(env map[string]string) func(string) string {
+	return func(key string) string {
+		return env[key]
+	}
+}
+
+func TestActionsLoad(t *testing.T) {
+	testCases := []struct {
+		name  string
+		env   map[string]string
+		check func(t *testing.T, ciVars *mail.CIVars)
+	}{
+		{
+			name: "push",
+			env: map[string]string{
+				"GITHUB_EVENT_NAME": "push",
+				"GITHUB_REF":        "refs/heads/master",
+				"GITHUB_REF_NAME":   "master",
+				"GITHUB_REF_TYPE":   "branch",
+				"GITHUB_SHA":        "9f2a1c0",
+			},
+			check: func(t *testing.T, ciVars *mail.CIVars) {
+				if ciVars.Build.Event != "push" || ciVars.Commit.Branch != "master" {
+					t.Errorf("expected push to master, got %s to %s", ciVars.Build.Event, ciVars.Commit.Branch)
+				}
+				if ciVars.Commit.Author.Email != "max@example.com" || ciVars.Commit.Author.Username != "max.mustermann" {
+					t.Errorf("expected author of the head commit, got %+v", ciVars.Commit.Author)
+				}
+				if ciVars.Commit.Before != "1b3d5e7" || ciVars.Commit.After != "9f2a1c0" {
+					t.Errorf("expected before and after of the payload, got %s and %s", ciVars.Commit.Before, ciVars.Commit.After)
+				}
+				if ciVars.Commit.ParsedMessage().Subject != "feat: add feature" {
+					t.Errorf("expected message of the head commit, got %s", ciVars.Commit.Message)
+				}
+				if !ciVars.Repo.Private || ciVars.Remote.URL != "https://github.com/volker.raschek/drone-email.git" {
+					t.Errorf("expected private repository with clone url, got %+v", ciVars.Repo)
+				}
+				if ciVars.PullRequest != 0 {
+					t.Errorf("expected no pull request, got %d", ciVars.PullRequest)
+				}
+			},
+		},
+		{
+			name: "pull_request",
+			env: map[string]string{
+				"GITHUB_ACTOR":      "max.mustermann",
+				"GITHUB_EVENT_NAME": "pull_request",
+				"GITHUB_HEAD_REF":   "feature",
+				"GITHUB_REF":        "refs/pull/12/merge",
+				"GITHUB_SHA":        "0a0a0a0",
+			},
+			check: func(t *testing.T, ciVars *mail.CIVars) {
+				if ciVars.Build.Event != "pull_request" || ciVars.PullRequest != 12 {
+					t.Errorf("expected pull request 12, got event %s and %d", ciVars.Build.Event, ciVars.PullRequest)
+				}
+				if ciVars.Commit.Sha != "4c8e2a9" || ciVars.Commit.Branch != "master" {
+					t.Errorf("expected head commit on target branch, got %s on %s", ciVars.Commit.Sha, ciVars.Commit.Branch)
+				}
+				if expected := "https://github.com/volker.raschek/drone-email/commit/4c8e2a9"; ciVars.Commit.Link != expected {
+					t.Errorf("expected link of the head commit %s, got %s", expected, ciVars.Commit.Link)
+				}
+				if len(ciVars.Commit.Message) > 0 {
+					t.Errorf("expected message not to be replaced by the pull request, got %s", ciVars.Commit.Message)
+				}
+				if ciVars.Commit.Author.Username != "max.mustermann" {
+					t.Errorf("expected commit author not to be replaced by the pull request author, got %s", ciVars.Commit.Author.Username)
+				}
+				if ciVars.PullRequestAuthor.Username != "erika.mustermann" || ciVars.PullRequestAuthor.Avatar != "https://github.com/erika.png" {
+					t.Errorf("expected pull request author erika.mustermann, got %+v", ciVars.PullRequestAuthor)
+				}
+				if ciVars.PullRequestLink != "https://github.com/volker.raschek/drone-email/pull/12" || ciVars.PullRequestTitle != "Add feature" {
+					t.Errorf("expected link and title of the pull request, got %s and %s", ciVars.PullRequestLink, ciVars.PullRequestTitle)
+				}
+				if ciVars.SourceBranch != "feature" || ciVars.TargetBranch != "master" {
+					t.Errorf("expected branches feature and master, got %s and %s", ciVars.SourceBranch, ciVars.TargetBranch)
+				}
+			},
+		},
+		{
+			name: "release",
+			env: map[string]string{
+				"GITHUB_EVENT_NAME": "release",
+				"GITHUB_REF":        "refs/tags/v1.2.0",
+				"GITHUB_REF_NAME":   "v1.2.0",
+				"GITHUB_REF_TYPE":   "tag",
+				"GITHUB_SHA":        "5e6f7a8",
+			},
+			check: func(t *testing.T, ciVars *mail.CIVars) {
+				if ciVars.Build.Event != "release" || ciVars.Tag != "v1.2.0" {
+					t.Errorf("expected release of tag v1.2.0, got %s of %s", ciVars.Build.Event, ciVars.Tag)
+				}
+				if ciVars.Commit.Link != "https://github.com/volker.raschek/drone-email/releases/tag/v1.2.0" {
+					t.Errorf("expected link of the release, got %s", ciVars.Commit.Link)
+				}
+				if ciVars.Commit.ParsedMessage().Subject != "v1.2.0" {
+					t.Errorf("expected name of the release as subject, got %s", ciVars.Commit.Message)
+				}
+				if ciVars.Commit.Author.Username != "max.mustermann" {
+					t.Errorf("expected author of the release, got %s", ciVars.Commit.Author.Username)
+				}
+			},
+		},
+		{
+			name: "workflow_run",
+			env: map[string]string{
+				"GITEA_ACTIONS":     "true",
+				"GITHUB_EVENT_NAME": "workflow_run",
+			},
+			check: func(t *testing.T, ciVars *mail.CIVars) {
+				if ciVars.Build.Status != "failure" {
+					t.Errorf("expected conclusion as build status, got %s", ciVars.Build.Status)
+				}
+				if ciVars.Build.Number != 7 || ciVars.Build.Link != "https://github.com/volker.raschek/drone-email/actions/runs/99" {
+					t.Errorf("expected number and link of the run, got %d and %s", ciVars.Build.Number, ciVars.Build.Link)
+				}
+				if ciVars.Build.Started-ciVars.Build.Created != 5 || ciVars.Build.Finished-ciVars.Build.Created != 300 {
+					t.Errorf("expected times of the run, got %+v", ciVars.Build)
+				}
+				if ciVars.Commit.Sha != "7d1e3f5" || ciVars.Commit.Branch != "master" || ciVars.Commit.Author.Email != "max@example.com" {
+					t.Errorf("expected head commit of the run, got %+v", ciVars.Commit)
+				}
+				if ciVars.Repo.SCM != domain.SCMGitea {
+					t.Errorf("expected scm gitea, got %s", ciVars.Repo.SCM)
+				}
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			env := map[string]string{
+				"GITHUB_ACTIONS":          "true",
+				"GITHUB_EVENT_PATH":       filepath.Join("testdata", "actions", testCase.name+".json"),
+				"GITHUB_REPOSITORY":       "volker.raschek/drone-email",
+				"GITHUB_REPOSITORY_OWNER": "volker.raschek",
+				"GITHUB_RUN_ID":           "99",
+				"GITHUB_RUN_NUMBER":       "7",
+				"GITHUB_SERVER_URL":       "https://github.com",
+			}
+			for key, value := range testCase.env {
+				env[key] = value
+			}
+
+			a := new(actions)
+			if !a.Detect(newGetenv(env)) {
+				t.Fatal("expected actions to be detected")
+			}
+
+			ciVars, err := a.Load(newGetenv(env))
+			if err != nil {
+				t.Fatalf("failed to load actions: %v", err)
+			}
+
+			if ciVars.Repo.FullName != "volker.raschek/drone-email" || ciVars.Repo.Name != "drone-email" {
+				t.Errorf("expected repository volker.raschek/drone-email, got %s", ciVars.Repo.FullName)
+			}
+
+			testCase.check(t, ciVars)
+		})
+	}
+}
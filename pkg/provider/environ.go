@@ -0,0 +1,114 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// environ reads typed values of environment variables. Parse errors are
+// collected and returned by Err.
+type environ struct {
+	errs   []error
+	getenv func(string) string
+}
+
+// Bool returns the boolean value of the first defined environment variable.
+func (e *environ) Bool(keys ...string) bool {
+	key, value := e.lookup(keys)
+	if len(value) <= 0 {
+		return false
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("failed to parse value of %s: %w", key, err))
+	}
+
+	return b
+}
+
+// Err returns the parse errors of all values.
+func (e *environ) Err() error {
+	return errors.Join(e.errs...)
+}
+
+// Int returns the integer value of the first defined environment variable.
+func (e *environ) Int(keys ...string) int {
+	key, value := e.lookup(keys)
+	if len(value) <= 0 {
+		return 0
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("failed to parse value of %s: %w", key, err))
+	}
+
+	return i
+}
+
+// Int64 returns the 64-bit integer value of the first defined environment
+// variable.
+func (e *environ) Int64(keys ...string) int64 {
+	key, value := e.lookup(keys)
+	if len(value) <= 0 {
+		return 0
+	}
+
+	i, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("failed to parse value of %s: %w", key, err))
+	}
+
+	return i
+}
+
+// List returns the comma separated values of the first defined environment
+// variable.
+func (e *environ) List(keys ...string) []string {
+	_, value := e.lookup(keys)
+
+	values := make([]string, 0)
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); len(v) > 0 {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+// String returns the value of the first defined environment variable.
+func (e *environ) String(keys ...string) string {
+	_, value := e.lookup(keys)
+	return value
+}
+
+// Time returns the unix timestamp of the RFC 3339 time of the first defined
+// environment variable.
+func (e *environ) Time(keys ...string) int64 {
+	key, value := e.lookup(keys)
+	if len(value) <= 0 {
+		return 0
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("failed to parse value of %s: %w", key, err))
+	}
+
+	return t.Unix()
+}
+
+func (e *environ) lookup(keys []string) (string, string) {
+	for _, key := range keys {
+		if value := e.getenv(key); len(value) > 0 {
+			return key, value
+		}
+	}
+
+	return "", ""
+}
@@ -0,0 +1,149 @@
+package provider
+
+import (
+	"fmt"
+	netmail "net/mail"
+	"strings"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/domain"
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/mail"
+)
+
+// gitlab maps the CI_* and GITLAB_* environment variables of GitLab CI.
+type gitlab struct{}
+
+func (g *gitlab) Detect(getenv func(string) string) bool {
+	return getenv("GITLAB_CI") == "true"
+}
+
+func (g *gitlab) Load(getenv func(string) string) (*mail.CIVars, error) {
+	env := &environ{getenv: getenv}
+
+	projectURL := strings.TrimSuffix(env.String("CI_PROJECT_URL"), "/")
+
+	ciVars := mail.NewCIVars()
+
+	ciVars.Build.Created = env.Time("CI_PIPELINE_CREATED_AT")
+	ciVars.Build.Event = gitlabEventName(env.String("CI_PIPELINE_SOURCE"), env.String("CI_COMMIT_TAG"))
+	ciVars.Build.Link = env.String("CI_PIPELINE_URL")
+	ciVars.Build.Number = env.Int("CI_PIPELINE_ID")
+	ciVars.Build.Started = env.Time("CI_JOB_STARTED_AT")
+	status, err := gitlabStatus(env.String("CI_JOB_STATUS"))
+	if err != nil {
+		return nil, err
+	}
+	ciVars.Build.Status = status
+
+	// The author of the commit, e.g. Max Mustermann <max@example.com>. The user,
+	// who started the pipeline, is used as fallback.
+	if author, err := netmail.ParseAddress(env.String("CI_COMMIT_AUTHOR")); err == nil {
+		ciVars.Commit.Author.Email = author.Address
+		ciVars.Commit.Author.Name = author.Name
+	} else {
+		ciVars.Commit.Author.Email = env.String("GITLAB_USER_EMAIL")
+		ciVars.Commit.Author.Name = env.String("GITLAB_USER_NAME")
+	}
+	ciVars.Commit.Author.Username = env.String("GITLAB_USER_LOGIN")
+	ciVars.Commit.Before = env.String("CI_COMMIT_BEFORE_SHA")
+	ciVars.Commit.Branch = env.String("CI_COMMIT_BRANCH", "CI_MERGE_REQUEST_TARGET_BRANCH_NAME")
+	ciVars.Commit.Message = env.String("CI_COMMIT_MESSAGE")
+	ciVars.Commit.Ref = env.String("CI_COMMIT_REF_NAME")
+	ciVars.Commit.Sha = env.String("CI_COMMIT_SHA")
+	if len(projectURL) > 0 && len(ciVars.Commit.Sha) > 0 {
+		ciVars.Commit.Link = fmt.Sprintf("%s/-/commit/%s", projectURL, ciVars.Commit.Sha)
+	}
+
+	ciVars.DeployTo = env.String("CI_ENVIRONMENT_NAME")
+
+	ciVars.Job.Number = env.Int("CI_JOB_ID")
+	ciVars.Job.Started = env.Time("CI_JOB_STARTED_AT")
+	ciVars.Job.Status = env.String("CI_JOB_STATUS")
+
+	ciVars.PullRequest = env.Int("CI_MERGE_REQUEST_IID")
+	ciVars.PullRequestTitle = env.String("CI_MERGE_REQUEST_TITLE")
+
+	// CI_REPOSITORY_URL contains a job token and is therefore not used.
+	if len(projectURL) > 0 {
+		ciVars.Remote.URL = projectURL + ".git"
+	}
+
+	ciVars.Repo.Branch = env.String("CI_DEFAULT_BRANCH")
+	ciVars.Repo.FullName = env.String("CI_PROJECT_PATH")
+	ciVars.Repo.Link = projectURL
+	ciVars.Repo.Name = env.String("CI_PROJECT_NAME")
+	ciVars.Repo.Owner = env.String("CI_PROJECT_NAMESPACE")
+	ciVars.Repo.Private = env.String("CI_PROJECT_VISIBILITY") != "public"
+	ciVars.Repo.SCM = domain.SCMGitLab
+
+	ciVars.SourceBranch = env.String("CI_MERGE_REQUEST_SOURCE_BRANCH_NAME")
+
+	// The architecture of the runner, e.g. linux/amd64
+	platform := strings.SplitN(env.String("CI_RUNNER_EXECUTABLE_ARCH"), "/", 2)
+	ciVars.Stage.OS = platform[0]
+	if len(platform) > 1 {
+		ciVars.Stage.Arch = platform[1]
+	}
+	ciVars.Stage.Name = env.String("CI_JOB_STAGE")
+
+	ciVars.Step.Name = env.String("CI_JOB_NAME")
+
+	ciVars.System.Host = env.String("CI_SERVER_HOST")
+	ciVars.System.Hostname = env.String("CI_SERVER_HOST")
+	ciVars.System.Proto = env.String("CI_SERVER_PROTOCOL")
+	ciVars.System.Version = env.String("CI_SERVER_VERSION")
+
+	ciVars.Tag = env.String("CI_COMMIT_TAG")
+	ciVars.TargetBranch = env.String("CI_MERGE_REQUEST_TARGET_BRANCH_NAME")
+
+	err = env.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return ciVars, nil
+}
+
+func (g *gitlab) Name() string {
+	return NameGitLab
+}
+
+// gitlabEventName returns the name of the drone event of the pipeline source.
+// Sources without drone equivalent, like trigger or api, are returned
+// unchanged.
+func gitlabEventName(source string, tag string) string {
+	switch source {
+	case "push":
+		if len(tag) > 0 {
+			return "tag"
+		}
+		return "push"
+	case "merge_request_event":
+		return "pull_request"
+	case "schedule":
+		return "cron"
+	case "web":
+		return "custom"
+	default:
+		return source
+	}
+}
+
+// gitlabStatus returns the drone status of the job status. The status of a job
+// is only success, failed or canceled inside of after_script. Otherwise the job
+// is running and its status is unknown, therefore an empty status is returned,
+// which must be defined via DRONE_BUILD_STATUS. Other statuses, like skipped or
+// manual, are not supported.
+func gitlabStatus(status string) (string, error) {
+	switch status {
+	case "success":
+		return domain.StatusSuccess, nil
+	case "failed":
+		return domain.StatusFailure, nil
+	case "canceled":
+		return domain.StatusKilled, nil
+	case "running", "":
+		return "", nil
+	default:
+		return "", fmt.Errorf("unsupported job status %s of CI_JOB_STATUS", status)
+	}
+}
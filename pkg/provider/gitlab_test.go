@@ -0,0 +1,47 @@
+package provider
+
+import (
+	"testing"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/domain"
+)
+
+func TestGitLabStatus(t *testing.T) {
+	testCases := []struct {
+		status      string
+		expected    string
+		expectedErr bool
+	}{
+		{status: "success", expected: domain.StatusSuccess},
+		{status: "failed", expected: domain.StatusFailure},
+		{status: "canceled", expected: domain.StatusKilled},
+		{status: "running", expected: ""},
+		{status: "", expected: ""},
+		{status: "skipped", expectedErr: true},
+		{status: "manual", expectedErr: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.status, func(t *testing.T) {
+			env := map[string]string{
+				"CI_JOB_STATUS":   testCase.status,
+				"CI_PROJECT_PATH": "volker.raschek/drone-email",
+				"GITLAB_CI":       "true",
+			}
+
+			ciVars, err := new(gitlab).Load(newGetenv(env))
+			switch {
+			case testCase.expectedErr && err == nil:
+				t.Fatalf("expected an error of job status %s", testCase.status)
+			case testCase.expectedErr:
+				return
+			case err != nil:
+				t.Fatalf("failed to load gitlab: %v", err)
+			}
+
+			if ciVars.Build.Status != testCase.expected {
+				t.Errorf("expected build status %q, got %q", testCase.expected, ciVars.Build.Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/mail"
+)
+
+const (
+	NameActions    = "actions"
+	NameAuto       = "auto"
+	NameDrone      = "drone"
+	NameGitLab     = "gitlab"
+	NameWoodpecker = "woodpecker"
+)
+
+// Provider maps the environment variables of a CI system onto the CI vars.
+type Provider interface {
+	// Detect returns true, if the environment variables have been defined by the
+	// CI system.
+	Detect(getenv func(string) string) bool
+
+	// Load returns the CI vars of the environment variables.
+	Load(getenv func(string) string) (*mail.CIVars, error)
+
+	// Name returns the name of the CI system, e.g. woodpecker.
+	Name() string
+}
+
+// providers are the providers of all supported CI systems except drone. The
+// variables of drone are defined via flags and loaded by the loader package.
+var providers = []Provider{
+	new(woodpecker),
+	new(actions),
+	new(gitlab),
+}
+
+// Detect returns the provider of the CI system, which defined the environment
+// variables. If no provider detects the CI system, nil is returned and drone is
+// assumed.
+func Detect(getenv func(string) string) Provider {
+	for _, p := range providers {
+		if p.Detect(getenv) {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// Lookup returns the provider by its name. The provider of drone and auto is
+// nil, because the variables of drone are defined via flags.
+func Lookup(name string) (Provider, error) {
+	switch name {
+	case NameAuto, NameDrone:
+		return nil, nil
+	}
+
+	names := []string{NameAuto, NameDrone}
+	for _, p := range providers {
+		if p.Name() == name {
+			return p, nil
+		}
+		names = append(names, p.Name())
+	}
+
+	return nil, fmt.Errorf("unsupported CI provider %s: expected %s", name, strings.Join(names, ", "))
+}
@@ -0,0 +1,103 @@
+package provider
+
+import (
+	"net/url"
+	"strings"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/mail"
+)
+
+// woodpecker maps the CI_* environment variables of Woodpecker CI. Variables
+// which have been renamed by Woodpecker, like CI_PIPELINE_LINK to
+// CI_PIPELINE_URL, are read by both names.
+type woodpecker struct{}
+
+func (w *woodpecker) Detect(getenv func(string) string) bool {
+	return getenv("CI") == NameWoodpecker
+}
+
+func (w *woodpecker) Load(getenv func(string) string) (*mail.CIVars, error) {
+	env := &environ{getenv: getenv}
+
+	ciVars := mail.NewCIVars()
+
+	ciVars.Build.Created = env.Int64("CI_PIPELINE_CREATED")
+	ciVars.Build.Event = env.String("CI_PIPELINE_EVENT")
+	ciVars.Build.Finished = env.Int64("CI_PIPELINE_FINISHED")
+	ciVars.Build.Link = env.String("CI_PIPELINE_URL", "CI_PIPELINE_LINK")
+	ciVars.Build.Number = env.Int("CI_PIPELINE_NUMBER")
+	ciVars.Build.Started = env.Int64("CI_PIPELINE_STARTED")
+	ciVars.Build.Status = env.String("CI_PIPELINE_STATUS")
+
+	ciVars.Commit.Author.Avatar = env.String("CI_COMMIT_AUTHOR_AVATAR")
+	ciVars.Commit.Author.Email = env.String("CI_COMMIT_AUTHOR_EMAIL")
+	ciVars.Commit.Author.Name = env.String("CI_COMMIT_AUTHOR")
+	ciVars.Commit.Author.Username = env.String("CI_COMMIT_AUTHOR")
+	ciVars.Commit.Branch = env.String("CI_COMMIT_BRANCH")
+	ciVars.Commit.Link = env.String("CI_PIPELINE_FORGE_URL", "CI_COMMIT_URL", "CI_COMMIT_LINK")
+	ciVars.Commit.Message = env.String("CI_COMMIT_MESSAGE")
+	ciVars.Commit.Ref = env.String("CI_COMMIT_REF")
+	ciVars.Commit.Sha = env.String("CI_COMMIT_SHA")
+
+	ciVars.DeployTo = env.String("CI_PIPELINE_DEPLOY_TARGET")
+
+	ciVars.Job.Finished = env.Int64("CI_STEP_FINISHED")
+	ciVars.Job.Started = env.Int64("CI_STEP_STARTED")
+	ciVars.Job.Status = env.String("CI_STEP_STATUS")
+
+	ciVars.Prev.Build.Number = env.Int("CI_PREV_PIPELINE_NUMBER")
+	ciVars.Prev.Build.Status = env.String("CI_PREV_PIPELINE_STATUS")
+	ciVars.Prev.Commit.Sha = env.String("CI_PREV_COMMIT_SHA")
+
+	ciVars.PullRequest = env.Int("CI_COMMIT_PULL_REQUEST")
+
+	ciVars.Remote.URL = env.String("CI_REPO_CLONE_URL", "CI_REPO_REMOTE")
+
+	ciVars.Repo.Branch = env.String("CI_REPO_DEFAULT_BRANCH")
+	ciVars.Repo.FullName = env.String("CI_REPO")
+	ciVars.Repo.Link = env.String("CI_REPO_URL", "CI_REPO_LINK")
+	ciVars.Repo.Name = env.String("CI_REPO_NAME")
+	ciVars.Repo.Owner = env.String("CI_REPO_OWNER")
+	ciVars.Repo.Private = env.Bool("CI_REPO_PRIVATE")
+	ciVars.Repo.SCM = env.String("CI_REPO_SCM", "CI_FORGE_TYPE")
+	ciVars.Repo.Trusted = env.Bool("CI_REPO_TRUSTED")
+
+	ciVars.SourceBranch = env.String("CI_COMMIT_SOURCE_BRANCH")
+
+	// The platform of the agent, e.g. linux/amd64
+	platform := strings.SplitN(env.String("CI_SYSTEM_PLATFORM"), "/", 2)
+	ciVars.Stage.OS = platform[0]
+	if len(platform) > 1 {
+		ciVars.Stage.Arch = platform[1]
+	}
+	ciVars.Stage.Name = env.String("CI_WORKFLOW_NAME")
+	ciVars.Stage.Number = env.Int("CI_WORKFLOW_NUMBER")
+
+	ciVars.Step.Name = env.String("CI_STEP_NAME")
+	ciVars.Step.Number = env.Int("CI_STEP_NUMBER")
+
+	ciVars.System.Host = env.String("CI_SYSTEM_HOST")
+	ciVars.System.Hostname = env.String("CI_SYSTEM_HOST")
+	ciVars.System.Version = env.String("CI_SYSTEM_VERSION")
+	if systemURL, err := url.Parse(env.String("CI_SYSTEM_URL")); err == nil && len(systemURL.Host) > 0 {
+		ciVars.System.Proto = systemURL.Scheme
+		if len(ciVars.System.Host) <= 0 {
+			ciVars.System.Host = systemURL.Host
+			ciVars.System.Hostname = systemURL.Hostname()
+		}
+	}
+
+	ciVars.Tag = env.String("CI_COMMIT_TAG")
+	ciVars.TargetBranch = env.String("CI_COMMIT_TARGET_BRANCH")
+
+	err := env.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return ciVars, nil
+}
+
+func (w *woodpecker) Name() string {
+	return NameWoodpecker
+}
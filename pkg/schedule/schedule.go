@@ -0,0 +1,180 @@
+package schedule
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// window is a daily time window in minutes since midnight. Windows whose start
+// is after their end span midnight, for example 22:00-06:00.
+type window struct {
+	start int
+	end   int
+}
+
+func (w window) contains(minute int) bool {
+	if w.start <= w.end {
+		return minute >= w.start && minute < w.end
+	}
+	return minute >= w.start || minute < w.end
+}
+
+// Schedule defines when mails may be sent. A point in time is part of the
+// schedule, if it is inside one of the daily time windows, on one of the
+// weekdays and not on a holiday.
+type Schedule struct {
+	holidays map[string]struct{}
+	location *time.Location
+	weekdays map[time.Weekday]struct{}
+	windows  []window
+}
+
+// Contains returns true if t is part of the schedule.
+func (s *Schedule) Contains(t time.Time) bool {
+	t = t.In(s.location)
+
+	if _, ok := s.holidays[t.Format(dateLayout)]; ok {
+		return false
+	}
+
+	if _, ok := s.weekdays[t.Weekday()]; !ok {
+		return false
+	}
+
+	if len(s.windows) <= 0 {
+		return true
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	for _, w := range s.windows {
+		if w.contains(minute) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// New returns a schedule of the time windows like 08:00-18:00, the weekdays
+// like mon or fri and the holidays of the holiday file in the timezone. Without
+// windows the whole day and without weekdays all weekdays are part of the
+// schedule.
+func New(windows []string, weekdayNames []string, holidayFile string, timezone string) (*Schedule, error) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load timezone %s: %w", timezone, err)
+	}
+
+	s := &Schedule{
+		holidays: make(map[string]struct{}),
+		location: location,
+		weekdays: make(map[time.Weekday]struct{}),
+		windows:  make([]window, 0, len(windows)),
+	}
+
+	for _, w := range windows {
+		parsedWindow, err := parseWindow(w)
+		if err != nil {
+			return nil, err
+		}
+		s.windows = append(s.windows, parsedWindow)
+	}
+
+	if len(weekdayNames) <= 0 {
+		weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+	}
+
+	for _, weekdayName := range weekdayNames {
+		// Accept abbreviations like mon as well as full names like monday.
+		name := strings.ToLower(strings.TrimSpace(weekdayName))
+		if len(name) > 3 {
+			name = name[:3]
+		}
+
+		weekday, ok := weekdays[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown weekday %s", weekdayName)
+		}
+		s.weekdays[weekday] = struct{}{}
+	}
+
+	if len(holidayFile) > 0 {
+		s.holidays, err = readHolidayFile(holidayFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+func parseWindow(s string) (window, error) {
+	start, end, ok := strings.Cut(s, "-")
+	if !ok {
+		return window{}, fmt.Errorf("invalid time window %s: expected format 08:00-18:00", s)
+	}
+
+	startTime, err := time.Parse("15:04", strings.TrimSpace(start))
+	if err != nil {
+		return window{}, fmt.Errorf("invalid start of time window %s: %w", s, err)
+	}
+
+	endTime, err := time.Parse("15:04", strings.TrimSpace(end))
+	if err != nil {
+		return window{}, fmt.Errorf("invalid end of time window %s: %w", s, err)
+	}
+
+	return window{
+		start: startTime.Hour()*60 + startTime.Minute(),
+		end:   endTime.Hour()*60 + endTime.Minute(),
+	}, nil
+}
+
+// readHolidayFile reads a file of holidays in the format 2006-01-02, one per
+// line. Empty lines and text after # are ignored.
+func readHolidayFile(name string) (map[string]struct{}, error) {
+	// #nosec G304
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	holidays := make(map[string]struct{})
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		text = strings.TrimSpace(text)
+		if len(text) <= 0 {
+			continue
+		}
+
+		date, err := time.Parse(dateLayout, strings.Fields(text)[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid holiday in line %d of %s: %w", line, name, err)
+		}
+		holidays[date.Format(dateLayout)] = struct{}{}
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	return holidays, nil
+}
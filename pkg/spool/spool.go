@@ -0,0 +1,102 @@
+package spool
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/fileutil"
+)
+
+const fileExtension = ".json"
+
+// Message is a rendered mail, which has not been delivered yet.
+type Message struct {
+	Data      []byte    `json:"data"`
+	Recipient string    `json:"recipient"`
+	Spooled   time.Time `json:"spooled"`
+}
+
+// Entry is a message of the spool directory.
+type Entry struct {
+	Message *Message
+	Path    string
+}
+
+// Remove removes the entry from the spool directory.
+func (e *Entry) Remove() error {
+	err := os.Remove(e.Path)
+	if err != nil {
+		return fmt.Errorf("failed to remove %s: %w", e.Path, err)
+	}
+
+	return nil
+}
+
+// Read returns all messages of the spool directory, ordered by the time they
+// have been spooled.
+func Read(dir string) ([]*Entry, error) {
+	dirEntries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []*Entry{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory %s: %w", dir, err)
+	}
+
+	entries := make([]*Entry, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || !strings.HasSuffix(dirEntry.Name(), fileExtension) {
+			continue
+		}
+
+		name := filepath.Join(dir, dirEntry.Name())
+		// #nosec G304
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+
+		message := new(Message)
+		err = json.Unmarshal(b, message)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", name, err)
+		}
+
+		entries = append(entries, &Entry{
+			Message: message,
+			Path:    name,
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Message.Spooled.Before(entries[j].Message.Spooled)
+	})
+
+	return entries, nil
+}
+
+// Write writes the messages into the spool directory. The directory is created
+// if it does not exist.
+func Write(dir string, messages ...*Message) error {
+	for i, message := range messages {
+		b, err := json.Marshal(message)
+		if err != nil {
+			return fmt.Errorf("failed to encode message: %w", err)
+		}
+
+		// Messages of the same build share the spool time, therefore the index is
+		// part of the file name.
+		name := filepath.Join(dir, fmt.Sprintf("%d-%d%s", message.Spooled.UnixNano(), i, fileExtension))
+		err = fileutil.WriteFile(name, b)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
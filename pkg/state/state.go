@@ -0,0 +1,159 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"git.cryptic.systems/volker.raschek/drone-email-docker/pkg/fileutil"
+)
+
+// Entry is a notification, which has been sent.
+type Entry struct {
+	Branch string    `json:"branch"`
+	Commit string    `json:"commit"`
+	Repo   string    `json:"repo"`
+	Sent   time.Time `json:"sent"`
+	Status string    `json:"status"`
+}
+
+// Equal returns true if both entries are notifications of the same repository,
+// branch, commit and status.
+func (e *Entry) Equal(o *Entry) bool {
+	return e.Repo == o.Repo &&
+		e.Branch == o.Branch &&
+		e.Commit == o.Commit &&
+		e.Status == o.Status
+}
+
+type file struct {
+	Entries []*Entry `json:"entries"`
+}
+
+// Store is a JSON file of sent notifications. Use Update to modify the file, so
+// that concurrent writers do not overwrite the entries of each other.
+type Store struct {
+	entries []*Entry
+	path    string
+}
+
+// Entries returns all entries, ordered by the time they have been sent.
+func (s *Store) Entries() []*Entry {
+	entries := make([]*Entry, len(s.entries))
+	copy(entries, s.entries)
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Sent.Before(entries[j].Sent)
+	})
+
+	return entries
+}
+
+// Lookup returns the entry of an equivalent notification or nil, if no such
+// notification has been sent.
+func (s *Store) Lookup(entry *Entry) *Entry {
+	for _, e := range s.entries {
+		if e.Equal(entry) {
+			return e
+		}
+	}
+
+	return nil
+}
+
+// Prune removes all entries sent before t and returns the number of removed
+// entries.
+func (s *Store) Prune(t time.Time) int {
+	entries := make([]*Entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		if e.Sent.Before(t) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+
+	n := len(s.entries) - len(entries)
+	s.entries = entries
+
+	return n
+}
+
+// Put adds the entry to the store. An existing entry of an equivalent
+// notification is replaced.
+func (s *Store) Put(entry *Entry) {
+	for i, e := range s.entries {
+		if e.Equal(entry) {
+			s.entries[i] = entry
+			return
+		}
+	}
+
+	s.entries = append(s.entries, entry)
+}
+
+// Remove removes the entry of an equivalent notification, which has been sent
+// at the same time as the entry.
+func (s *Store) Remove(entry *Entry) {
+	entries := make([]*Entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		if e.Equal(entry) && e.Sent.Equal(entry.Sent) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+
+	s.entries = entries
+}
+
+// Save writes the store into its file. The directory of the file is created if
+// it does not exist.
+func (s *Store) Save() error {
+	b, err := json.Marshal(&file{Entries: s.Entries()})
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	return fileutil.WriteFile(s.path, b)
+}
+
+// Open reads the store of the file. If the file does not exist, an empty store
+// is returned, which creates the file on Save.
+func Open(path string) (*Store, error) {
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Store{entries: make([]*Entry, 0), path: path}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	f := new(file)
+	err = json.Unmarshal(b, f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+
+	return &Store{entries: f.Entries, path: path}, nil
+}
+
+// Update locks the store file, passes the store to f and saves it afterwards.
+// Concurrent updates of the same file are serialized. If f returns an error,
+// the store is not saved and the error is returned.
+func Update(path string, f func(s *Store) error) error {
+	return fileutil.WithLock(path, func() error {
+		store, err := Open(path)
+		if err != nil {
+			return err
+		}
+
+		err = f(store)
+		if err != nil {
+			return err
+		}
+
+		return store.Save()
+	})
+}
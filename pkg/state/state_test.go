@@ -0,0 +1,83 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUpdateConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	now := time.Now()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- Update(path, func(s *Store) error {
+				s.Put(&Entry{Commit: fmt.Sprintf("%d", i), Sent: now})
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("failed to update state store: %v", err)
+		}
+	}
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to open state store: %v", err)
+	}
+	if n := len(store.Entries()); n != 20 {
+		t.Errorf("expected 20 entries, got %d", n)
+	}
+
+	_, err = os.Stat(path + ".lock")
+	if !os.IsNotExist(err) {
+		t.Errorf("expected lock file to be removed, got %v", err)
+	}
+}
+
+func TestUpdateError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	expectedErr := fmt.Errorf("skip")
+	err := Update(path, func(s *Store) error {
+		s.Put(&Entry{Commit: "abc"})
+		return expectedErr
+	})
+	if err != expectedErr {
+		t.Fatalf("expected error %v, got %v", expectedErr, err)
+	}
+
+	_, err = os.Stat(path)
+	if !os.IsNotExist(err) {
+		t.Errorf("expected state store not to be saved, got %v", err)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	now := time.Now()
+	store := &Store{}
+	store.Put(&Entry{Commit: "abc", Status: "failure", Sent: now})
+
+	store.Remove(&Entry{Commit: "abc", Status: "failure", Sent: now.Add(-time.Minute)})
+	if n := len(store.Entries()); n != 1 {
+		t.Fatalf("expected entry of another time to be kept, got %d entries", n)
+	}
+
+	store.Remove(&Entry{Commit: "abc", Status: "failure", Sent: now})
+	if n := len(store.Entries()); n != 0 {
+		t.Errorf("expected entry to be removed, got %d entries", n)
+	}
+}